@@ -78,6 +78,39 @@ type ApplyFunc[K Object, A Adoptable[A]] func(ctx context.Context, object A, opt
 // error otherwise.
 type ExistsFunc func(ctx context.Context, nn types.NamespacedName) error
 
+// LabelsFunc should return the current labels of the object in the cluster.
+type LabelsFunc func(ctx context.Context, nn types.NamespacedName) (map[string]string, error)
+
+// ChangeType identifies the kind of change the AdoptionHandler applies to an
+// object.
+type ChangeType string
+
+const (
+	// ChangeLabel labels an object so that it is visible to the controller
+	ChangeLabel ChangeType = "label"
+	// ChangeAdopt annotates an object as owned by an owner
+	ChangeAdopt ChangeType = "adopt"
+	// ChangeRelease removes an owner's annotation from an object that the
+	// owner no longer references
+	ChangeRelease ChangeType = "release"
+	// ChangeCleanup removes the controller's labels from an object that no
+	// longer has any owners
+	ChangeCleanup ChangeType = "cleanup"
+)
+
+// PlannedChange is a change the AdoptionHandler would have applied if it
+// wasn't in DryRun mode.
+type PlannedChange[A any] struct {
+	Type    ChangeType
+	Object  types.NamespacedName
+	Patch   A
+	Options metav1.ApplyOptions
+}
+
+// PlannedChangeFunc is called with each change an AdoptionHandler plans to
+// apply in DryRun mode.
+type PlannedChangeFunc[A any] func(ctx context.Context, change PlannedChange[A])
+
 // IndexKeyFunc returns the name of an index to use and the value to query it for.
 type IndexKeyFunc func(ctx context.Context) (indexName string, indexValue string)
 
@@ -109,7 +142,8 @@ type AdoptionHandler[K Object, A Adoptable[A]] struct {
 	// revert the changes performed elsewhere in the controller.
 	ControllerFieldManager string
 
-	// AdopteeCtx tells the handler how to fetch the adoptee from context
+	// AdopteeCtx tells the handler how to fetch the adoptee from context.
+	// Cluster-scoped adoptees have an empty namespace.
 	AdopteeCtx typedctx.MustValueContext[types.NamespacedName]
 
 	// OwnerCtx tells the handler how to fetch the owner from context
@@ -145,12 +179,16 @@ type AdoptionHandler[K Object, A Adoptable[A]] struct {
 
 	// NewPatch returns an empty object satisfying Adoptable
 	// This is typically an apply configuration, like `applycorev1.Secret(name, namespace)`
+	// or, for cluster-scoped objects, `applyrbacv1.ClusterRole(name)`
 	NewPatch func(types.NamespacedName) A
 
 	// OwnerAnnotationPrefix is a common prefix for all owner annotations
 	OwnerAnnotationPrefix string
 
 	// OwnerAnnotationKeyFunc generates an ownership annotation key for a given owner
+	// For cluster-scoped adoptees, the key must also identify the owner's
+	// namespace: use ClusterScopedOwnerAnnotationKeyFunc, and index the
+	// objects with OwnerKeysFromClusterScopedMeta.
 	OwnerAnnotationKeyFunc func(owner types.NamespacedName) string
 
 	// OwnerFieldManagerFunc generates a field manager name for a given owner
@@ -162,6 +200,33 @@ type AdoptionHandler[K Object, A Adoptable[A]] struct {
 	// ExistsFunc checks if the object to be adopted exists in the cluster
 	ExistsFunc ExistsFunc
 
+	// LabelsFunc, if set, fetches the labels of an object that isn't in the
+	// cache (typically with an uncached client). If the object already
+	// carries all of Labels, i.e. because they are managed by another
+	// controller or tool, the handler doesn't apply them again.
+	LabelsFunc LabelsFunc
+
+	// LabelWithoutForce applies Labels without forcing ownership of them.
+	// Labels that aren't managed by anyone else are merged into the existing
+	// labels as usual, but if another field manager owns one of the labels
+	// with a different value, the apply fails with a conflict instead of
+	// taking the label over.
+	LabelWithoutForce bool
+
+	// Metrics, if set, counts the changes the handler makes
+	Metrics *Metrics
+
+	// DryRun makes the same decisions as normal, but passes the resulting
+	// changes to PlannedChangeFunc instead of applying them with ApplyFunc.
+	// Since nothing is adopted, ObjectAdoptedFunc is not called, and
+	// AdoptedCtx holds the object from the cache (if any) rather than the
+	// result of an apply.
+	DryRun bool
+
+	// PlannedChangeFunc is called with each change that would have been
+	// applied in DryRun mode
+	PlannedChangeFunc PlannedChangeFunc[A]
+
 	// Next is the next handler in the chain (use NoopHandler if not chaining)
 	Next handler.ContextHandler
 }
@@ -182,7 +247,7 @@ func (s *AdoptionHandler[K, A]) Handle(ctx context.Context) {
 	// adoptee may be empty, but the handler still runs so that it can clean up
 	// any old references that may remain.
 
-	_, err := s.GetFromCache(ctx)
+	cached, err := s.GetFromCache(ctx)
 	if err != nil && !errors.IsNotFound(err) {
 		s.RequeueErr(ctx, err)
 		return
@@ -198,17 +263,29 @@ func (s *AdoptionHandler[K, A]) Handle(ctx context.Context) {
 			s.ObjectMissingFunc(ctx, err)
 			return
 		}
-		logger.V(5).Info("labelling object to make it visible to the index",
-			"adoptee", adoptee.String(),
-			"manager", s.ControllerFieldManager,
-			"labels", s.Labels)
-		_, err := s.ApplyFunc(ctx,
-			s.NewPatch(adoptee).WithLabels(s.Labels),
-			metav1.ApplyOptions{Force: true, FieldManager: s.ControllerFieldManager})
+		labelled, err := s.hasLabels(ctx, adoptee)
 		if err != nil {
+			s.Metrics.apiError(s.ControllerFieldManager)
 			s.RequeueAPIErr(ctx, err)
 			return
 		}
+		if labelled {
+			logger.V(5).Info("object is already labelled, skipping labelling",
+				"adoptee", adoptee.String(),
+				"labels", s.Labels)
+		} else {
+			logger.V(5).Info("labelling object to make it visible to the index",
+				"adoptee", adoptee.String(),
+				"manager", s.ControllerFieldManager,
+				"labels", s.Labels)
+			_, err := s.apply(ctx, ChangeLabel, adoptee,
+				s.NewPatch(adoptee).WithLabels(s.Labels),
+				metav1.ApplyOptions{Force: !s.LabelWithoutForce, FieldManager: s.ControllerFieldManager})
+			if err != nil {
+				s.RequeueAPIErr(ctx, err)
+				return
+			}
+		}
 	}
 
 	// TODO: should the index value be configurable?
@@ -242,7 +319,7 @@ func (s *AdoptionHandler[K, A]) Handle(ctx context.Context) {
 		logger.V(5).Info("annotating object to adopt it",
 			"adoptee", adoptee.String(),
 			"owner", owner.String())
-		obj, err := s.ApplyFunc(ctx, s.NewPatch(adoptee).
+		obj, err := s.apply(ctx, ChangeAdopt, adoptee, s.NewPatch(adoptee).
 			WithAnnotations(map[string]string{ownerAnnotationKey: Owned}),
 			metav1.ApplyOptions{Force: true, FieldManager: s.OwnerFieldManagerFunc(owner)})
 		if err != nil {
@@ -250,8 +327,12 @@ func (s *AdoptionHandler[K, A]) Handle(ctx context.Context) {
 			return
 		}
 
-		s.ObjectAdoptedFunc(ctx, obj)
-		ctx = s.AdoptedCtx.WithValue(ctx, obj)
+		if s.DryRun {
+			ctx = s.AdoptedCtx.WithValue(ctx, cached)
+		} else {
+			s.ObjectAdoptedFunc(ctx, obj)
+			ctx = s.AdoptedCtx.WithValue(ctx, obj)
+		}
 	} else {
 		ctx = s.AdoptedCtx.WithValue(ctx, matchingObject)
 	}
@@ -268,7 +349,7 @@ func (s *AdoptionHandler[K, A]) Handle(ctx context.Context) {
 				logger.V(5).Info("marking object unowned",
 					"object", nn.String(),
 					"manager", s.OwnerFieldManagerFunc(owner))
-				_, err := s.ApplyFunc(ctx,
+				_, err := s.apply(ctx, ChangeRelease, nn,
 					s.NewPatch(nn).WithAnnotations(map[string]string{}),
 					metav1.ApplyOptions{Force: true, FieldManager: s.OwnerFieldManagerFunc(owner)})
 				if err != nil {
@@ -293,7 +374,7 @@ func (s *AdoptionHandler[K, A]) Handle(ctx context.Context) {
 			logger.V(5).Info("removing controller label",
 				"object", nn.String(),
 				"manager", s.ControllerFieldManager)
-			_, err := s.ApplyFunc(ctx,
+			_, err := s.apply(ctx, ChangeCleanup, nn,
 				s.NewPatch(nn).WithLabels(map[string]string{}),
 				metav1.ApplyOptions{Force: true, FieldManager: s.ControllerFieldManager})
 			if err != nil {
@@ -305,3 +386,49 @@ func (s *AdoptionHandler[K, A]) Handle(ctx context.Context) {
 
 	s.Next.Handle(ctx)
 }
+
+// apply applies patch to the object with ApplyFunc and counts the change, or
+// passes it to PlannedChangeFunc in DryRun mode.
+func (s *AdoptionHandler[K, A]) apply(ctx context.Context, change ChangeType, nn types.NamespacedName, patch A, opts metav1.ApplyOptions) (K, error) {
+	if s.DryRun {
+		logr.FromContextOrDiscard(ctx).V(4).Info("dry run, skipping apply",
+			"change", change,
+			"object", nn.String(),
+			"manager", opts.FieldManager)
+		if s.PlannedChangeFunc != nil {
+			s.PlannedChangeFunc(ctx, PlannedChange[A]{
+				Type:    change,
+				Object:  nn,
+				Patch:   patch,
+				Options: opts,
+			})
+		}
+		var zero K
+		return zero, nil
+	}
+	obj, err := s.ApplyFunc(ctx, patch, opts)
+	if err != nil {
+		s.Metrics.apiError(s.ControllerFieldManager)
+		return obj, err
+	}
+	s.Metrics.applied(change, s.ControllerFieldManager)
+	return obj, nil
+}
+
+// hasLabels returns true if the object in the cluster already has all of the
+// handler's Labels. It always returns false if there is no LabelsFunc.
+func (s *AdoptionHandler[K, A]) hasLabels(ctx context.Context, nn types.NamespacedName) (bool, error) {
+	if s.LabelsFunc == nil {
+		return false, nil
+	}
+	labels, err := s.LabelsFunc(ctx, nn)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range s.Labels {
+		if existing, ok := labels[k]; !ok || existing != v {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -2,18 +2,23 @@ package adopt
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	applyrbacv1 "k8s.io/client-go/applyconfigurations/rbac/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
 
 	"github.com/authzed/controller-idioms/handler"
 	"github.com/authzed/controller-idioms/queue"
@@ -368,6 +373,463 @@ func TestSecretAdopterHandler(t *testing.T) {
 	}
 }
 
+func TestAdoptionHandlerExistingLabels(t *testing.T) {
+	type applied struct {
+		patch *applycorev1.SecretApplyConfiguration
+		opts  metav1.ApplyOptions
+	}
+	labelPatch := applycorev1.Secret("secret", "test").
+		WithLabels(map[string]string{ManagedLabelKey: ManagedLabelValue})
+	annotationPatch := applycorev1.Secret("secret", "test").
+		WithAnnotations(map[string]string{OwnerAnnotationPrefix + "test": Owned})
+	ownerApply := applied{
+		patch: annotationPatch,
+		opts:  metav1.ApplyOptions{Force: true, FieldManager: "my-owner-test-test"},
+	}
+
+	tests := []struct {
+		name                string
+		labelsFunc          LabelsFunc
+		labelWithoutForce   bool
+		expectApplied       []applied
+		expectRequeueAPIErr error
+	}{
+		{
+			name: "no labels func",
+			expectApplied: []applied{
+				{patch: labelPatch, opts: metav1.ApplyOptions{Force: true, FieldManager: "test-controller"}},
+				ownerApply,
+			},
+		},
+		{
+			name: "already labelled by another tool",
+			labelsFunc: func(_ context.Context, _ types.NamespacedName) (map[string]string, error) {
+				return map[string]string{ManagedLabelKey: ManagedLabelValue, "other": "label"}, nil
+			},
+			expectApplied: []applied{ownerApply},
+		},
+		{
+			name: "labelled with a different value",
+			labelsFunc: func(_ context.Context, _ types.NamespacedName) (map[string]string, error) {
+				return map[string]string{ManagedLabelKey: "other-controller"}, nil
+			},
+			expectApplied: []applied{
+				{patch: labelPatch, opts: metav1.ApplyOptions{Force: true, FieldManager: "test-controller"}},
+				ownerApply,
+			},
+		},
+		{
+			name: "not labelled, without force",
+			labelsFunc: func(_ context.Context, _ types.NamespacedName) (map[string]string, error) {
+				return map[string]string{"other": "label"}, nil
+			},
+			labelWithoutForce: true,
+			expectApplied: []applied{
+				{patch: labelPatch, opts: metav1.ApplyOptions{Force: false, FieldManager: "test-controller"}},
+				ownerApply,
+			},
+		},
+		{
+			name: "labels func error",
+			labelsFunc: func(_ context.Context, _ types.NamespacedName) (map[string]string, error) {
+				return nil, apierrors.NewServiceUnavailable("unavailable")
+			},
+			expectApplied:       []applied{},
+			expectRequeueAPIErr: apierrors.NewServiceUnavailable("unavailable"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrls := &fake.FakeInterface{}
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{IndexName: OwnerKeysFromMeta(OwnerAnnotationPrefix)})
+			gotApplied := make([]applied, 0)
+			nextCalled := false
+			h := newTestAdoptionHandler(
+				typed.NewIndexer[*corev1.Secret](indexer),
+				func(_ context.Context) (*corev1.Secret, error) {
+					return nil, apierrors.NewNotFound(corev1.Resource("secrets"), "secret")
+				},
+				func(_ context.Context, secret *applycorev1.SecretApplyConfiguration, opts metav1.ApplyOptions) (*corev1.Secret, error) {
+					gotApplied = append(gotApplied, applied{patch: secret, opts: opts})
+					return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "test"}}, nil
+				},
+				handler.NewHandlerFromFunc(func(_ context.Context) {
+					nextCalled = true
+				}, "testnext"),
+			)
+			h.LabelsFunc = tt.labelsFunc
+			h.LabelWithoutForce = tt.labelWithoutForce
+			ctx := CtxOwnerNN.WithValue(context.Background(), types.NamespacedName{Namespace: "test", Name: "test"})
+			ctx = CtxSecretNN.WithValue(ctx, types.NamespacedName{Namespace: "test", Name: "secret"})
+			ctx = QueueOps.WithValue(ctx, ctrls)
+			h.Handle(ctx)
+
+			require.Equal(t, tt.expectApplied, gotApplied)
+			if tt.expectRequeueAPIErr != nil {
+				require.Equal(t, 1, ctrls.RequeueAPIErrCallCount())
+				require.Equal(t, tt.expectRequeueAPIErr, ctrls.RequeueAPIErrArgsForCall(0))
+				require.False(t, nextCalled)
+				return
+			}
+			require.True(t, nextCalled)
+		})
+	}
+}
+
+func TestAdoptionHandlerMetrics(t *testing.T) {
+	tests := []struct {
+		name           string
+		owner          string
+		secretInCache  *corev1.Secret
+		secretsInIndex []*corev1.Secret
+		applyErr       error
+		expected       string
+	}{
+		{
+			name:           "secret needs adopting",
+			owner:          "test",
+			secretsInIndex: []*corev1.Secret{},
+			expected: `
+# HELP adopt_adoptions_total [ALPHA] Number of owner annotations added to adopt objects
+# TYPE adopt_adoptions_total counter
+adopt_adoptions_total{manager="test-controller"} 1
+`,
+		},
+		{
+			name:           "secret adopted by a second owner",
+			owner:          "test2",
+			secretInCache:  ownedSecret("secret", "test"),
+			secretsInIndex: []*corev1.Secret{ownedSecret("secret", "test")},
+			expected: `
+# HELP adopt_adoptions_total [ALPHA] Number of owner annotations added to adopt objects
+# TYPE adopt_adoptions_total counter
+adopt_adoptions_total{manager="test-controller"} 1
+`,
+		},
+		{
+			name:           "old secret still in index",
+			owner:          "test",
+			secretInCache:  ownedSecret("secret", "test"),
+			secretsInIndex: []*corev1.Secret{ownedSecret("secret", "test"), ownedSecret("secret2", "test")},
+			expected: `
+# HELP adopt_cleanups_total [ALPHA] Number of controller labels removed from objects with no remaining owners
+# TYPE adopt_cleanups_total counter
+adopt_cleanups_total{manager="test-controller"} 1
+# HELP adopt_releases_total [ALPHA] Number of owner annotations removed from objects no longer referenced by the owner
+# TYPE adopt_releases_total counter
+adopt_releases_total{manager="test-controller"} 1
+`,
+		},
+		{
+			name:           "old secret still in index, still has other owners",
+			owner:          "test",
+			secretInCache:  ownedSecret("secret", "test"),
+			secretsInIndex: []*corev1.Secret{ownedSecret("secret", "test"), ownedSecret("secret2", "test", "test2")},
+			expected: `
+# HELP adopt_releases_total [ALPHA] Number of owner annotations removed from objects no longer referenced by the owner
+# TYPE adopt_releases_total counter
+adopt_releases_total{manager="test-controller"} 1
+`,
+		},
+		{
+			name:           "transient error adopting secret",
+			owner:          "test",
+			secretsInIndex: []*corev1.Secret{},
+			applyErr:       apierrors.NewTooManyRequestsError("server having issues"),
+			expected: `
+# HELP adopt_api_errors_total [ALPHA] Number of kube API errors while adopting objects
+# TYPE adopt_api_errors_total counter
+adopt_api_errors_total{manager="test-controller"} 1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := metrics.NewKubeRegistry()
+			m, err := NewMetrics(registry)
+			require.NoError(t, err)
+
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{IndexName: OwnerKeysFromMeta(OwnerAnnotationPrefix)})
+			IndexAddUnstructured(t, indexer, tt.secretsInIndex)
+			h := newTestAdoptionHandler(
+				typed.NewIndexer[*corev1.Secret](indexer),
+				func(_ context.Context) (*corev1.Secret, error) {
+					if tt.secretInCache == nil {
+						return nil, apierrors.NewNotFound(corev1.Resource("secrets"), "secret")
+					}
+					return tt.secretInCache, nil
+				},
+				func(_ context.Context, _ *applycorev1.SecretApplyConfiguration, _ metav1.ApplyOptions) (*corev1.Secret, error) {
+					return ownedSecret("secret", tt.owner), tt.applyErr
+				},
+				handler.NoopHandler,
+			)
+			h.Metrics = m
+
+			ctx := CtxOwnerNN.WithValue(context.Background(), types.NamespacedName{Namespace: "test", Name: tt.owner})
+			ctx = CtxSecretNN.WithValue(ctx, types.NamespacedName{Namespace: "test", Name: "secret"})
+			ctx = QueueOps.WithValue(ctx, &fake.FakeInterface{})
+			h.Handle(ctx)
+
+			require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(tt.expected),
+				"adopt_adoptions_total", "adopt_releases_total", "adopt_cleanups_total", "adopt_api_errors_total"))
+		})
+	}
+}
+
+func TestAdoptionHandlerDryRun(t *testing.T) {
+	tests := []struct {
+		name           string
+		owner          string
+		secretInCache  *corev1.Secret
+		secretsInIndex []*corev1.Secret
+		expectChanges  []ChangeType
+	}{
+		{
+			name:           "secret needs adopting",
+			owner:          "test",
+			secretsInIndex: []*corev1.Secret{},
+			expectChanges:  []ChangeType{ChangeLabel, ChangeAdopt},
+		},
+		{
+			name:           "secret adopted by a second owner",
+			owner:          "test2",
+			secretInCache:  ownedSecret("secret", "test"),
+			secretsInIndex: []*corev1.Secret{ownedSecret("secret", "test")},
+			expectChanges:  []ChangeType{ChangeAdopt},
+		},
+		{
+			name:           "secret already adopted",
+			owner:          "test",
+			secretInCache:  ownedSecret("secret", "test"),
+			secretsInIndex: []*corev1.Secret{ownedSecret("secret", "test")},
+			expectChanges:  []ChangeType{},
+		},
+		{
+			name:           "old secret still in index",
+			owner:          "test",
+			secretInCache:  ownedSecret("secret", "test"),
+			secretsInIndex: []*corev1.Secret{ownedSecret("secret", "test"), ownedSecret("secret2", "test")},
+			expectChanges:  []ChangeType{ChangeRelease, ChangeCleanup},
+		},
+		{
+			name:           "old secret still in index, still has other owners",
+			owner:          "test",
+			secretInCache:  ownedSecret("secret", "test"),
+			secretsInIndex: []*corev1.Secret{ownedSecret("secret", "test"), ownedSecret("secret2", "test", "test2")},
+			expectChanges:  []ChangeType{ChangeRelease},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := func(dryRun bool, applyFunc ApplyFunc[*corev1.Secret, *applycorev1.SecretApplyConfiguration]) (planned []PlannedChange[*applycorev1.SecretApplyConfiguration], adopted int, ctxSecret *corev1.Secret) {
+				indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{IndexName: OwnerKeysFromMeta(OwnerAnnotationPrefix)})
+				IndexAddUnstructured(t, indexer, tt.secretsInIndex)
+				nextCalled := false
+				h := newTestAdoptionHandler(
+					typed.NewIndexer[*corev1.Secret](indexer),
+					func(_ context.Context) (*corev1.Secret, error) {
+						if tt.secretInCache == nil {
+							return nil, apierrors.NewNotFound(corev1.Resource("secrets"), "secret")
+						}
+						return tt.secretInCache, nil
+					},
+					applyFunc,
+					handler.NewHandlerFromFunc(func(ctx context.Context) {
+						nextCalled = true
+						ctxSecret = CtxSecret.Value(ctx)
+					}, "testnext"),
+				)
+				h.DryRun = dryRun
+				h.PlannedChangeFunc = func(_ context.Context, change PlannedChange[*applycorev1.SecretApplyConfiguration]) {
+					planned = append(planned, change)
+				}
+				h.ObjectAdoptedFunc = func(_ context.Context, _ *corev1.Secret) {
+					adopted++
+				}
+
+				ctx := CtxOwnerNN.WithValue(context.Background(), types.NamespacedName{Namespace: "test", Name: tt.owner})
+				ctx = CtxSecretNN.WithValue(ctx, types.NamespacedName{Namespace: "test", Name: "secret"})
+				ctx = QueueOps.WithValue(ctx, &fake.FakeInterface{})
+				h.Handle(ctx)
+				require.True(t, nextCalled)
+				return planned, adopted, ctxSecret
+			}
+
+			// the normal path, recording what was applied
+			applied := make([]PlannedChange[*applycorev1.SecretApplyConfiguration], 0)
+			run(false, func(_ context.Context, secret *applycorev1.SecretApplyConfiguration, opts metav1.ApplyOptions) (*corev1.Secret, error) {
+				applied = append(applied, PlannedChange[*applycorev1.SecretApplyConfiguration]{
+					Object:  types.NamespacedName{Namespace: *secret.Namespace, Name: *secret.Name},
+					Patch:   secret,
+					Options: opts,
+				})
+				return ownedSecret(*secret.Name, tt.owner), nil
+			})
+
+			planned, adopted, ctxSecret := run(true, func(_ context.Context, _ *applycorev1.SecretApplyConfiguration, _ metav1.ApplyOptions) (*corev1.Secret, error) {
+				require.Fail(t, "ApplyFunc called in dry run")
+				return nil, nil
+			})
+			require.Zero(t, adopted)
+			require.Equal(t, tt.secretInCache, ctxSecret)
+
+			changes := make([]ChangeType, 0, len(planned))
+			for i := range planned {
+				changes = append(changes, planned[i].Type)
+				planned[i].Type = ""
+			}
+			require.Equal(t, tt.expectChanges, changes)
+			require.Len(t, planned, len(applied))
+			for i := range applied {
+				require.Equal(t, applied[i], planned[i])
+			}
+		})
+	}
+}
+
+func TestClusterScopedAdoption(t *testing.T) {
+	var (
+		ctxRoleNN = typedctx.WithDefault[types.NamespacedName](types.NamespacedName{})
+		ctxRole   = typedctx.WithDefault[*rbacv1.ClusterRole](nil)
+		owner     = types.NamespacedName{Namespace: "test", Name: "owner"}
+		ownedRole = func(name string) *rbacv1.ClusterRole {
+			return &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      map[string]string{ManagedLabelKey: ManagedLabelValue},
+				Annotations: map[string]string{OwnerAnnotationPrefix + "test.owner": Owned},
+			}}
+		}
+	)
+
+	type applied struct {
+		patch *applyrbacv1.ClusterRoleApplyConfiguration
+		opts  metav1.ApplyOptions
+	}
+	run := func(t *testing.T, roleName string, inCache *rbacv1.ClusterRole, inIndex []*rbacv1.ClusterRole) ([]applied, *rbacv1.ClusterRole) {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{IndexName: OwnerKeysFromClusterScopedMeta(OwnerAnnotationPrefix)})
+		IndexAddUnstructured(t, indexer, inIndex)
+		gotApplied := make([]applied, 0)
+		var ctxObj *rbacv1.ClusterRole
+		h := &AdoptionHandler[*rbacv1.ClusterRole, *applyrbacv1.ClusterRoleApplyConfiguration]{
+			OperationsContext:      QueueOps,
+			ControllerFieldManager: "test-controller",
+			AdopteeCtx:             ctxRoleNN,
+			OwnerCtx:               CtxOwnerNN,
+			AdoptedCtx:             ctxRole,
+			ObjectAdoptedFunc:      func(_ context.Context, _ *rbacv1.ClusterRole) {},
+			GetFromCache: func(_ context.Context) (*rbacv1.ClusterRole, error) {
+				if inCache == nil {
+					return nil, apierrors.NewNotFound(rbacv1.Resource("clusterroles"), roleName)
+				}
+				return inCache, nil
+			},
+			Indexer:   typed.NewIndexer[*rbacv1.ClusterRole](indexer),
+			IndexName: IndexName,
+			Labels:    map[string]string{ManagedLabelKey: ManagedLabelValue},
+			NewPatch: func(nn types.NamespacedName) *applyrbacv1.ClusterRoleApplyConfiguration {
+				require.Empty(t, nn.Namespace)
+				return applyrbacv1.ClusterRole(nn.Name)
+			},
+			OwnerAnnotationPrefix:  OwnerAnnotationPrefix,
+			OwnerAnnotationKeyFunc: ClusterScopedOwnerAnnotationKeyFunc(OwnerAnnotationPrefix),
+			OwnerFieldManagerFunc: func(owner types.NamespacedName) string {
+				return "my-owner-" + owner.Namespace + "-" + owner.Name
+			},
+			ApplyFunc: func(_ context.Context, role *applyrbacv1.ClusterRoleApplyConfiguration, opts metav1.ApplyOptions) (*rbacv1.ClusterRole, error) {
+				gotApplied = append(gotApplied, applied{patch: role, opts: opts})
+				return ownedRole(*role.Name), nil
+			},
+			Next: handler.NewHandlerFromFunc(func(ctx context.Context) {
+				ctxObj = ctxRole.Value(ctx)
+			}, "testnext"),
+		}
+		ctx := CtxOwnerNN.WithValue(context.Background(), owner)
+		ctx = ctxRoleNN.WithValue(ctx, types.NamespacedName{Name: roleName})
+		ctx = QueueOps.WithValue(ctx, &fake.FakeInterface{})
+		h.Handle(ctx)
+		return gotApplied, ctxObj
+	}
+
+	// adopting the role labels and annotates it without a namespace, and
+	// records the owner's namespace in the annotation
+	gotApplied, ctxObj := run(t, "role", nil, nil)
+	require.Equal(t, []applied{
+		{
+			patch: applyrbacv1.ClusterRole("role").WithLabels(map[string]string{ManagedLabelKey: ManagedLabelValue}),
+			opts:  metav1.ApplyOptions{Force: true, FieldManager: "test-controller"},
+		},
+		{
+			patch: applyrbacv1.ClusterRole("role").WithAnnotations(map[string]string{OwnerAnnotationPrefix + "test.owner": Owned}),
+			opts:  metav1.ApplyOptions{Force: true, FieldManager: "my-owner-test-owner"},
+		},
+	}, gotApplied)
+	for _, a := range gotApplied {
+		require.Nil(t, a.patch.Namespace)
+	}
+	require.Equal(t, ownedRole("role"), ctxObj)
+
+	// once adopted, the role is found in the owner's index and nothing is
+	// applied
+	gotApplied, ctxObj = run(t, "role", ownedRole("role"), []*rbacv1.ClusterRole{ownedRole("role")})
+	require.Empty(t, gotApplied)
+	require.Equal(t, ownedRole("role"), ctxObj)
+
+	// when the owner references another role, the old one is released and
+	// cleaned up without a namespace
+	gotApplied, _ = run(t, "role2", ownedRole("role2"), []*rbacv1.ClusterRole{ownedRole("role"), ownedRole("role2")})
+	require.Equal(t, []applied{
+		{
+			patch: applyrbacv1.ClusterRole("role").WithAnnotations(map[string]string{}),
+			opts:  metav1.ApplyOptions{Force: true, FieldManager: "my-owner-test-owner"},
+		},
+		{
+			patch: applyrbacv1.ClusterRole("role").WithLabels(map[string]string{}),
+			opts:  metav1.ApplyOptions{Force: true, FieldManager: "test-controller"},
+		},
+	}, gotApplied)
+}
+
+func ownedSecret(name string, owners ...string) *corev1.Secret {
+	annotations := make(map[string]string, len(owners))
+	for _, o := range owners {
+		annotations[OwnerAnnotationPrefix+o] = Owned
+	}
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   "test",
+		Labels:      map[string]string{ManagedLabelKey: ManagedLabelValue},
+		Annotations: annotations,
+	}}
+}
+
+func newTestAdoptionHandler(indexer *typed.Indexer[*corev1.Secret], getFromCache func(ctx context.Context) (*corev1.Secret, error), applyFunc ApplyFunc[*corev1.Secret, *applycorev1.SecretApplyConfiguration], next handler.ContextHandler) *AdoptionHandler[*corev1.Secret, *applycorev1.SecretApplyConfiguration] {
+	return &AdoptionHandler[*corev1.Secret, *applycorev1.SecretApplyConfiguration]{
+		OperationsContext:      QueueOps,
+		ControllerFieldManager: "test-controller",
+		AdopteeCtx:             CtxSecretNN,
+		OwnerCtx:               CtxOwnerNN,
+		AdoptedCtx:             CtxSecret,
+		ObjectAdoptedFunc:      func(_ context.Context, _ *corev1.Secret) {},
+		GetFromCache:           getFromCache,
+		Indexer:                indexer,
+		IndexName:              IndexName,
+		Labels:                 map[string]string{ManagedLabelKey: ManagedLabelValue},
+		NewPatch: func(nn types.NamespacedName) *applycorev1.SecretApplyConfiguration {
+			return applycorev1.Secret(nn.Name, nn.Namespace)
+		},
+		OwnerAnnotationPrefix: OwnerAnnotationPrefix,
+		OwnerAnnotationKeyFunc: func(owner types.NamespacedName) string {
+			return OwnerAnnotationPrefix + owner.Name
+		},
+		OwnerFieldManagerFunc: func(owner types.NamespacedName) string {
+			return "my-owner-" + owner.Namespace + "-" + owner.Name
+		},
+		ApplyFunc: applyFunc,
+		Next:      next,
+	}
+}
+
 func NewSecretAdoptionHandler(recorder record.EventRecorder, getFromCache func(ctx context.Context) (*corev1.Secret, error), missingFunc func(context.Context, error), secretIndexer *typed.Indexer[*corev1.Secret], secretApplyFunc ApplyFunc[*corev1.Secret, *applycorev1.SecretApplyConfiguration], secretExistsFunc ExistsFunc, next handler.Handler) handler.Handler {
 	return handler.NewHandler(&AdoptionHandler[*corev1.Secret, *applycorev1.SecretApplyConfiguration]{
 		OperationsContext:      QueueOps,
@@ -0,0 +1,69 @@
+package adopt
+
+import (
+	"k8s.io/component-base/metrics"
+)
+
+// Metrics counts the changes AdoptionHandlers make to adopted objects. It is
+// optional: set AdoptionHandler.Metrics to record them.
+//
+// Each counter is labelled by the handler's ControllerFieldManager, so that
+// one Metrics can be shared by the handlers of several controllers.
+type Metrics struct {
+	// Adoptions counts owner annotations added to adopt objects
+	Adoptions *metrics.CounterVec
+	// Releases counts owner annotations removed from objects that are no
+	// longer referenced by the owner
+	Releases *metrics.CounterVec
+	// Cleanups counts controller labels removed from objects that no longer
+	// have any owner
+	Cleanups *metrics.CounterVec
+	// APIErrors counts errors from the kube API while adopting, releasing,
+	// or cleaning up objects
+	APIErrors *metrics.CounterVec
+}
+
+// NewMetrics creates the adoption counters and registers them in registry.
+func NewMetrics(registry metrics.KubeRegistry) (*Metrics, error) {
+	newCounter := func(name, help string) *metrics.CounterVec {
+		return metrics.NewCounterVec(&metrics.CounterOpts{
+			Subsystem:      "adopt",
+			Name:           name,
+			Help:           help,
+			StabilityLevel: metrics.ALPHA,
+		}, []string{"manager"})
+	}
+	m := &Metrics{
+		Adoptions: newCounter("adoptions_total", "Number of owner annotations added to adopt objects"),
+		Releases:  newCounter("releases_total", "Number of owner annotations removed from objects no longer referenced by the owner"),
+		Cleanups:  newCounter("cleanups_total", "Number of controller labels removed from objects with no remaining owners"),
+		APIErrors: newCounter("api_errors_total", "Number of kube API errors while adopting objects"),
+	}
+	for _, c := range []*metrics.CounterVec{m.Adoptions, m.Releases, m.Cleanups, m.APIErrors} {
+		if err := registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// applied counts a change applied by a handler with the given manager.
+func (m *Metrics) applied(change ChangeType, manager string) {
+	if m == nil {
+		return
+	}
+	switch change {
+	case ChangeAdopt:
+		m.Adoptions.WithLabelValues(manager).Inc()
+	case ChangeRelease:
+		m.Releases.WithLabelValues(manager).Inc()
+	case ChangeCleanup:
+		m.Cleanups.WithLabelValues(manager).Inc()
+	}
+}
+
+func (m *Metrics) apiError(manager string) {
+	if m != nil {
+		m.APIErrors.WithLabelValues(manager).Inc()
+	}
+}
@@ -10,7 +10,8 @@ import (
 
 // OwnerKeysFromMeta returns a set of namespace/name keys for the owners
 // of adopted objects with `annotationPrefix`. The namespace is always set
-// to the namespace of the object passed in.
+// to the namespace of the object passed in, so this only works for namespaced
+// objects; see OwnerKeysFromClusterScopedMeta for cluster-scoped objects.
 func OwnerKeysFromMeta(annotationPrefix string) func(in any) ([]string, error) {
 	return func(in any) ([]string, error) {
 		obj := in.(runtime.Object)
@@ -31,3 +32,45 @@ func OwnerKeysFromMeta(annotationPrefix string) func(in any) ([]string, error) {
 		return ownerNames, nil
 	}
 }
+
+// ClusterScopedOwnerAnnotationKeyFunc returns an OwnerAnnotationKeyFunc for
+// adopting cluster-scoped objects. Since a cluster-scoped object has no
+// namespace to infer the owner's namespace from, the annotation key holds
+// both, as `<annotationPrefix><namespace>.<name>`. Use it together with
+// OwnerKeysFromClusterScopedMeta.
+func ClusterScopedOwnerAnnotationKeyFunc(annotationPrefix string) func(owner types.NamespacedName) string {
+	return func(owner types.NamespacedName) string {
+		return annotationPrefix + owner.Namespace + "." + owner.Name
+	}
+}
+
+// OwnerKeysFromClusterScopedMeta returns a set of namespace/name keys for the
+// owners of adopted cluster-scoped objects with `annotationPrefix`, from
+// annotations written with ClusterScopedOwnerAnnotationKeyFunc.
+// Namespaces can't contain dots, so the owner's namespace is everything up to
+// the first dot and its name is the rest. Annotations without a dot are
+// skipped.
+func OwnerKeysFromClusterScopedMeta(annotationPrefix string) func(in any) ([]string, error) {
+	return func(in any) ([]string, error) {
+		obj := in.(runtime.Object)
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		ownerNames := make([]string, 0)
+		for k := range objMeta.GetAnnotations() {
+			if !strings.HasPrefix(k, annotationPrefix) {
+				continue
+			}
+			namespace, name, ok := strings.Cut(strings.TrimPrefix(k, annotationPrefix), ".")
+			if !ok {
+				continue
+			}
+			nn := types.NamespacedName{Name: name, Namespace: namespace}
+			ownerNames = append(ownerNames, nn.String())
+		}
+
+		return ownerNames, nil
+	}
+}
@@ -2,21 +2,28 @@ package bootstrap
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"path"
+	"sort"
 	"time"
 
+	"k8s.io/apiextensions-apiserver/pkg/apihelpers"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apiextensionsv1client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/pointer"
 )
 
 const (
@@ -33,33 +40,67 @@ func CRD(restConfig *rest.Config, crdFS fs.ReadDirFS, dir string) error {
 
 // CRDs installs the CRDs in the filesystem into the kube cluster configured by the rest config.
 func CRDs(ctx context.Context, restConfig *rest.Config, crdFS fs.ReadDirFS, dir string) error {
+	crds, err := readCRDs(crdFS, dir)
+	if err != nil {
+		return err
+	}
+
+	return CreateCRDs(ctx, restConfig, crds)
+}
+
+// CreateCRDs installs the CRDs into the kube cluster configured by the rest
+// config, and waits for them to be available. It can be used in place of CRDs
+// when the CRDs are already parsed, e.g. if they are built in code.
+func CreateCRDs(ctx context.Context, restConfig *rest.Config, crds []*apiextensionsv1.CustomResourceDefinition) error {
+	if err := createCRDs(ctx, restConfig, crds); err != nil {
+		return err
+	}
+
+	return waitForDiscovery(ctx, restConfig, crds)
+}
+
+// CRDsApply installs the CRDs in the filesystem into the kube cluster
+// configured by the rest config using server-side apply with the given field
+// manager. Unlike CRDs, fields set by other managers that aren't in the CRD
+// files are left alone, and repeated applies of the same CRDs are no-ops.
+// Fields that conflict with other managers are taken over by fieldManager.
+func CRDsApply(ctx context.Context, restConfig *rest.Config, crdFS fs.ReadDirFS, dir string, fieldManager string) error {
+	crds, err := readCRDs(crdFS, dir)
+	if err != nil {
+		return err
+	}
+
+	c, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	if err := applyCRDs(ctx, c.ApiextensionsV1().CustomResourceDefinitions(), crds, fieldManager); err != nil {
+		return err
+	}
+
+	return waitForDiscovery(ctx, restConfig, crds)
+}
+
+// readCRDs decodes each of the files in dir as a CRD
+func readCRDs(crdFS fs.ReadDirFS, dir string) ([]*apiextensionsv1.CustomResourceDefinition, error) {
 	crds := make([]*apiextensionsv1.CustomResourceDefinition, 0)
 
 	crdFiles, err := crdFS.ReadDir(dir)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, crdFile := range crdFiles {
 		var crd apiextensionsv1.CustomResourceDefinition
 		file, err := crdFS.Open(path.Join(dir, crdFile.Name()))
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if err := yaml.NewYAMLOrJSONDecoder(file, lookaheadBytes).Decode(&crd); err != nil {
-			return err
+			return nil, err
 		}
 		crds = append(crds, &crd)
 	}
-
-	if err := createCRDs(ctx, restConfig, crds); err != nil {
-		return err
-	}
-
-	if err := waitForDiscovery(ctx, restConfig, crds); err != nil {
-		return err
-	}
-
-	return err
+	return crds, nil
 }
 
 // createCRDs creates (or updates) CRDs in the cluster
@@ -97,27 +138,73 @@ func createCRDs(ctx context.Context, config *rest.Config, crds []*apiextensionsv
 	return nil
 }
 
-func waitForDiscovery(ctx context.Context, config *rest.Config, crds []*apiextensionsv1.CustomResourceDefinition) error {
-	resourcesByGV := make(map[string]map[string]struct{}, 0)
+// applyCRDs server-side applies CRDs to the cluster
+func applyCRDs(ctx context.Context, crdClient apiextensionsv1client.CustomResourceDefinitionInterface, crds []*apiextensionsv1.CustomResourceDefinition, fieldManager string) error {
 	for _, crd := range crds {
-		for _, version := range crd.Spec.Versions {
-			if !version.Served {
-				continue
-			}
-			gv := schema.GroupVersion{Version: version.Name, Group: crd.Spec.Group}.String()
-			_, ok := resourcesByGV[gv]
-			if !ok {
-				resourcesByGV[gv] = make(map[string]struct{}, 0)
-			}
-			resourcesByGV[gv][crd.Spec.Names.Plural] = struct{}{}
+		crd := crd.DeepCopy()
+		// apply requires the type to be set, even if the file omitted it
+		crd.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition"))
+		// the server rejects applied objects with a resourceVersion that
+		// doesn't match, and managed fields can't be applied at all
+		crd.SetResourceVersion("")
+		crd.SetManagedFields(nil)
+		data, err := json.Marshal(crd)
+		if err != nil {
+			return fmt.Errorf("unable to encode CRD %q: %w", crd.Name, err)
+		}
+		if _, err := crdClient.Patch(ctx, crd.Name, types.ApplyPatchType, data, metav1.PatchOptions{
+			FieldManager: fieldManager,
+			Force:        pointer.Bool(true),
+		}); err != nil {
+			return fmt.Errorf("unable to apply CRD %q: %w", crd.Name, err)
 		}
 	}
+	return nil
+}
+
+func waitForDiscovery(ctx context.Context, config *rest.Config, crds []*apiextensionsv1.CustomResourceDefinition) error {
+	c, err := clientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
 	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
 	if err != nil {
 		return err
 	}
+	return waitForEstablished(ctx, c.ApiextensionsV1().CustomResourceDefinitions(), discoveryClient, crds)
+}
+
+// waitForEstablished blocks until each of the CRDs is established and all of
+// its served versions are listed in discovery.
+//
+// The served versions are re-read from the cluster on every poll rather than
+// taken from crds, so that the wait tracks the current state of the CRD even
+// if its versions change while waiting. Discovery can briefly keep listing
+// versions that have been removed; these are ignored.
+func waitForEstablished(ctx context.Context, crdClient apiextensionsv1client.CustomResourceDefinitionInterface, discoveryClient discovery.DiscoveryInterface, crds []*apiextensionsv1.CustomResourceDefinition) error {
+	return wait.PollUntilContextTimeout(ctx, crdInstallPollInterval, maxCRDInstallTime, true, func(ctx context.Context) (done bool, err error) {
+		resourcesByGV := make(map[string]map[string]struct{}, 0)
+		for _, crd := range crds {
+			current, err := crdClient.Get(ctx, crd.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return false, nil
+			}
+			if !apihelpers.IsCRDConditionTrue(current, apiextensionsv1.Established) {
+				return false, nil
+			}
+			for _, version := range current.Spec.Versions {
+				if !version.Served {
+					continue
+				}
+				gv := schema.GroupVersion{Version: version.Name, Group: current.Spec.Group}.String()
+				_, ok := resourcesByGV[gv]
+				if !ok {
+					resourcesByGV[gv] = make(map[string]struct{}, 0)
+				}
+				resourcesByGV[gv][current.Spec.Names.Plural] = struct{}{}
+			}
+		}
 
-	return wait.PollUntilContextTimeout(ctx, crdInstallPollInterval, maxCRDInstallTime, true, func(_ context.Context) (done bool, err error) {
 		_, serverGVRs, err := discoveryClient.ServerGroupsAndResources()
 		if err != nil {
 			return false, nil
@@ -138,3 +225,33 @@ func waitForDiscovery(ctx context.Context, config *rest.Config, crds []*apiexten
 		return len(resourcesByGV) == 0, nil
 	})
 }
+
+// WaitForListable blocks until a List request succeeds for each of the gvrs.
+// Discovery can report a newly installed CRD before the apiserver is ready to
+// serve it, so this can be used after CRDs to ensure informers for the new
+// types can start without racing the installation.
+func WaitForListable(ctx context.Context, dclient dynamic.Interface, gvrs ...schema.GroupVersionResource) error {
+	remaining := make(map[schema.GroupVersionResource]struct{}, len(gvrs))
+	for _, gvr := range gvrs {
+		remaining[gvr] = struct{}{}
+	}
+
+	err := wait.PollUntilContextTimeout(ctx, crdInstallPollInterval, maxCRDInstallTime, true, func(ctx context.Context) (done bool, err error) {
+		for gvr := range remaining {
+			if _, err := dclient.Resource(gvr).List(ctx, metav1.ListOptions{Limit: 1}); err != nil {
+				continue
+			}
+			delete(remaining, gvr)
+		}
+		return len(remaining) == 0, nil
+	})
+	if err != nil {
+		notListable := make([]string, 0, len(remaining))
+		for gvr := range remaining {
+			notListable = append(notListable, gvr.String())
+		}
+		sort.Strings(notListable)
+		return fmt.Errorf("resources not listable %v: %w", notListable, err)
+	}
+	return nil
+}
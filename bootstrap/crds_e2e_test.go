@@ -5,11 +5,15 @@ package bootstrap
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/utils/pointer"
@@ -39,3 +43,99 @@ func TestCRD(t *testing.T) {
 	}}, metav1.CreateOptions{})
 	require.NoError(t, err)
 }
+
+func TestWaitForListableTimeout(t *testing.T) {
+	opts := genericclioptions.NewConfigFlags(true)
+	opts.KubeConfig = pointer.String("../controller-idioms-e2e.kubeconfig")
+	factory := cmdutil.NewFactory(opts)
+	restConfig, err := factory.ToRESTConfig()
+	require.NoError(t, err)
+	require.NoError(t, CRDs(context.Background(), restConfig, crdFS, "example"))
+
+	client, err := factory.DynamicClient()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err = WaitForListable(ctx, client, schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}, schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "missingtypes",
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorContains(t, err, "resources not listable [example.com/v1, Resource=missingtypes]")
+}
+
+func TestCRDsApply(t *testing.T) {
+	opts := genericclioptions.NewConfigFlags(true)
+	opts.KubeConfig = pointer.String("../controller-idioms-e2e.kubeconfig")
+	factory := cmdutil.NewFactory(opts)
+	restConfig, err := factory.ToRESTConfig()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, CRDsApply(ctx, restConfig, crdFS, "example", "controller-idioms-e2e"))
+
+	// another manager owns a field that isn't in the CRD files
+	c, err := clientset.NewForConfig(restConfig)
+	require.NoError(t, err)
+	crdClient := c.ApiextensionsV1().CustomResourceDefinitions()
+	_, err = crdClient.Patch(ctx, "mytypes.example.com", types.ApplyPatchType, []byte(`{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind": "CustomResourceDefinition",
+		"metadata": {"name": "mytypes.example.com", "labels": {"example.com/other": "kept"}}
+	}`), metav1.PatchOptions{FieldManager: "other-manager"})
+	require.NoError(t, err)
+	before, err := crdClient.Get(ctx, "mytypes.example.com", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// applying again is a no-op that leaves the other manager's field alone
+	require.NoError(t, CRDsApply(ctx, restConfig, crdFS, "example", "controller-idioms-e2e"))
+	after, err := crdClient.Get(ctx, "mytypes.example.com", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, before.ResourceVersion, after.ResourceVersion)
+	require.Equal(t, "kept", after.Labels["example.com/other"])
+}
+
+func TestCreateCRDs(t *testing.T) {
+	opts := genericclioptions.NewConfigFlags(true)
+	opts.KubeConfig = pointer.String("../controller-idioms-e2e.kubeconfig")
+	factory := cmdutil.NewFactory(opts)
+	restConfig, err := factory.ToRESTConfig()
+	require.NoError(t, err)
+
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "othertypes.example.com"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.com",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:     "OtherType",
+				ListKind: "OtherTypeList",
+				Plural:   "othertypes",
+				Singular: "othertype",
+			},
+			Scope: apiextensionsv1.NamespaceScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name:    "v1",
+				Served:  true,
+				Storage: true,
+				Schema: &apiextensionsv1.CustomResourceValidation{
+					OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{Type: "object"},
+				},
+			}},
+		},
+	}
+	require.NoError(t, CreateCRDs(context.Background(), restConfig, []*apiextensionsv1.CustomResourceDefinition{crd}))
+
+	client, err := factory.DynamicClient()
+	require.NoError(t, err)
+	require.NoError(t, WaitForListable(context.Background(), client, schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "othertypes",
+	}))
+}
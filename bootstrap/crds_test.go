@@ -3,8 +3,22 @@ package bootstrap
 import (
 	"context"
 	"embed"
+	"sync/atomic"
+	"testing"
+	"time"
 
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
 )
 
 //go:embed example/*.yaml
@@ -14,3 +28,173 @@ func ExampleCRD() {
 	_ = CRDs(context.Background(), &rest.Config{}, crdFS, "example")
 	// Output:
 }
+
+func ExampleCreateCRDs() {
+	_ = CreateCRDs(context.Background(), &rest.Config{}, []*apiextensionsv1.CustomResourceDefinition{
+		newTestCRD(false, "v1"),
+	})
+	// Output:
+}
+
+func TestWaitForListable(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		gvr: "MyTypeList",
+	})
+
+	// the first few lists fail as if the CRD was not yet served
+	var lists atomic.Int32
+	client.PrependReactor("list", gvr.Resource, func(_ clienttesting.Action) (bool, runtime.Object, error) {
+		if lists.Add(1) <= 2 {
+			return true, nil, apierrors.NewNotFound(gvr.GroupResource(), "")
+		}
+		return false, nil, nil
+	})
+
+	require.NoError(t, WaitForListable(context.Background(), client, gvr))
+	require.Equal(t, int32(3), lists.Load())
+}
+
+func TestWaitForListableCancelled(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		gvr: "MyTypeList",
+	})
+	client.PrependReactor("list", gvr.Resource, func(_ clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(gvr.GroupResource(), "")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	require.Error(t, WaitForListable(ctx, client, gvr))
+}
+
+func newTestCRD(established bool, versions ...string) *apiextensionsv1.CustomResourceDefinition {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "mytypes.example.com"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.com",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "mytypes", Kind: "MyType"},
+		},
+	}
+	for _, v := range versions {
+		crd.Spec.Versions = append(crd.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{Name: v, Served: true})
+	}
+	if established {
+		crd.Status.Conditions = []apiextensionsv1.CustomResourceDefinitionCondition{{
+			Type:   apiextensionsv1.Established,
+			Status: apiextensionsv1.ConditionTrue,
+		}}
+	}
+	return crd
+}
+
+func discoveredVersions(versions ...string) []*metav1.APIResourceList {
+	lists := make([]*metav1.APIResourceList, 0, len(versions))
+	for _, v := range versions {
+		lists = append(lists, &metav1.APIResourceList{
+			GroupVersion: "example.com/" + v,
+			APIResources: []metav1.APIResource{{Name: "mytypes", Kind: "MyType"}},
+		})
+	}
+	return lists
+}
+
+func TestWaitForEstablished(t *testing.T) {
+	tests := []struct {
+		name       string
+		desired    *apiextensionsv1.CustomResourceDefinition
+		inCluster  *apiextensionsv1.CustomResourceDefinition
+		discovered []*metav1.APIResourceList
+		wantErr    bool
+	}{
+		{
+			name:       "established and discovered",
+			desired:    newTestCRD(false, "v1"),
+			inCluster:  newTestCRD(true, "v1"),
+			discovered: discoveredVersions("v1"),
+		},
+		{
+			name:       "removed version still in discovery",
+			desired:    newTestCRD(false, "v1"),
+			inCluster:  newTestCRD(true, "v1"),
+			discovered: discoveredVersions("v1alpha1", "v1"),
+		},
+		{
+			name:       "versions changed in cluster while waiting",
+			desired:    newTestCRD(false, "v1alpha1", "v1"),
+			inCluster:  newTestCRD(true, "v1"),
+			discovered: discoveredVersions("v1"),
+		},
+		{
+			name:       "not established",
+			desired:    newTestCRD(false, "v1"),
+			inCluster:  newTestCRD(false, "v1"),
+			discovered: discoveredVersions("v1"),
+			wantErr:    true,
+		},
+		{
+			name:       "served version not discovered",
+			desired:    newTestCRD(false, "v1"),
+			inCluster:  newTestCRD(true, "v1"),
+			discovered: discoveredVersions("v1alpha1"),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			client := apiextensionsfake.NewSimpleClientset(tt.inCluster)
+			client.Discovery().(*fakediscovery.FakeDiscovery).Resources = tt.discovered
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			err := waitForEstablished(ctx, client.ApiextensionsV1().CustomResourceDefinitions(), client.Discovery(), []*apiextensionsv1.CustomResourceDefinition{tt.desired})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestApplyCRDs(t *testing.T) {
+	crds, err := readCRDs(crdFS, "example")
+	require.NoError(t, err)
+
+	// an older copy of the CRD, with an annotation set by another manager
+	existing := newTestCRD(true, "v1alpha1")
+	existing.SetAnnotations(map[string]string{"other-manager": "value"})
+	client := apiextensionsfake.NewSimpleClientset(existing)
+	crdClient := client.ApiextensionsV1().CustomResourceDefinitions()
+
+	require.NoError(t, applyCRDs(context.Background(), crdClient, crds, "test-manager"))
+	applied, err := crdClient.Get(context.Background(), "mytypes.example.com", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "value", applied.GetAnnotations()["other-manager"])
+	require.Equal(t, "MyTypeList", applied.Spec.Names.ListKind)
+
+	// applying again doesn't change anything
+	require.NoError(t, applyCRDs(context.Background(), crdClient, crds, "test-manager"))
+	reapplied, err := crdClient.Get(context.Background(), "mytypes.example.com", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, applied, reapplied)
+
+	patches := 0
+	for _, action := range client.Actions() {
+		if patch, ok := action.(clienttesting.PatchAction); ok {
+			require.Equal(t, types.ApplyPatchType, patch.GetPatchType())
+			patches++
+		}
+	}
+	require.Equal(t, 2, patches)
+}
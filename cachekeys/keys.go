@@ -2,46 +2,105 @@
 // keys for use in client-go caches and workqueus.
 //
 // These allow one to build queues that process multiple types of objects by
-// annotating the standard namespace/name keys with the GVR.
+// annotating the standard namespace/name keys with the GVR. Queues that only
+// process a single type can use the plain namespace/name keys from
+// MetaNamespaceKeyer instead.
 package cachekeys
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/cache"
 )
 
 // GVRMetaNamespaceKeyer creates a cache/queue key from a gvr and an object key
+// (as created by MetaNamespaceKeyer or cache.MetaNamespaceKeyFunc). Keys are
+// of the form resource.version.group::key; the group is last since it is the
+// only part of the gvr that can contain dots.
 func GVRMetaNamespaceKeyer(gvr schema.GroupVersionResource, key string) string {
 	return fmt.Sprintf("%s.%s.%s::%s", gvr.Resource, gvr.Version, gvr.Group, key)
 }
 
 // GVRMetaNamespaceKeyFunc creates cache/queue key from a gvr and an object.
+// Tombstones (cache.DeletedFinalStateUnknown) are keyed like the object they
+// hold, so that deletes observed late produce the same key.
 func GVRMetaNamespaceKeyFunc(gvr schema.GroupVersionResource, obj interface{}) (string, error) {
 	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
-		return d.Key, nil
+		if objMeta, err := meta.Accessor(d.Obj); err == nil {
+			return GVRMetaNamespaceKeyer(gvr, MetaNamespaceKeyer(objMeta.GetNamespace(), objMeta.GetName())), nil
+		}
+		// the tombstone's key comes from cache.MetaNamespaceKeyFunc, which
+		// doesn't escape names, so it can only be re-keyed if the name has no
+		// slashes (as is the case for all valid kube object names)
+		namespace, name, err := cache.SplitMetaNamespaceKey(d.Key)
+		if err != nil {
+			return "", fmt.Errorf("error parsing tombstone key %q: %w", d.Key, err)
+		}
+		return GVRMetaNamespaceKeyer(gvr, MetaNamespaceKeyer(namespace, name)), nil
 	}
-	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if key, ok := obj.(cache.ExplicitKey); ok {
+		return GVRMetaNamespaceKeyer(gvr, string(key)), nil
+	}
+	objMeta, err := meta.Accessor(obj)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("object has no meta: %w", err)
 	}
-	return GVRMetaNamespaceKeyer(gvr, key), nil
+	return GVRMetaNamespaceKeyer(gvr, MetaNamespaceKeyer(objMeta.GetNamespace(), objMeta.GetName())), nil
 }
 
 // SplitGVRMetaNamespaceKey splits a cache key into gvr, namespace, and name.
 func SplitGVRMetaNamespaceKey(key string) (gvr *schema.GroupVersionResource, namespace, name string, err error) {
 	before, after, ok := strings.Cut(key, "::")
 	if !ok {
-		err = fmt.Errorf("error parsing key: %s", key)
-		return
+		return nil, "", "", fmt.Errorf("error parsing key %q: missing gvr separator", key)
+	}
+	// the group may contain dots, so it takes everything after the version
+	parts := strings.SplitN(before, ".", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return nil, "", "", fmt.Errorf("error parsing key %q: gvr %q is not of the form resource.version.group", key, before)
+	}
+	namespace, name, err = SplitMetaNamespaceKey(after)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("error parsing key %q: %w", key, err)
+	}
+	return &schema.GroupVersionResource{Group: parts[2], Version: parts[1], Resource: parts[0]}, namespace, name, nil
+}
+
+// MetaNamespaceKeyer creates a cache/queue key from a namespace and name,
+// without a gvr. Keys are of the form namespace/name, or just name for
+// cluster-scoped objects, which matches client-go's cache.MetaNamespaceKeyFunc
+// for valid object names. Names are escaped so that names containing slashes
+// can still be split by SplitMetaNamespaceKey.
+func MetaNamespaceKeyer(namespace, name string) string {
+	name = url.PathEscape(name)
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// SplitMetaNamespaceKey splits a key created by MetaNamespaceKeyer into
+// namespace and name. The namespace is empty for cluster-scoped objects.
+func SplitMetaNamespaceKey(key string) (namespace, name string, err error) {
+	parts := strings.Split(key, "/")
+	switch len(parts) {
+	case 1:
+		name = parts[0]
+	case 2:
+		namespace, name = parts[0], parts[1]
+	default:
+		return "", "", fmt.Errorf("unexpected key format: %q", key)
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("empty name in key: %q", key)
 	}
-	gvr, _ = schema.ParseResourceArg(before)
-	if gvr == nil {
-		err = fmt.Errorf("error parsing gvr from key: %s", before)
-		return
+	name, err = url.PathUnescape(name)
+	if err != nil {
+		return "", "", fmt.Errorf("error unescaping name in key %q: %w", key, err)
 	}
-	namespace, name, err = cache.SplitMetaNamespaceKey(after)
-	return
+	return namespace, name, nil
 }
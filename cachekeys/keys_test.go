@@ -0,0 +1,162 @@
+package cachekeys
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestMetaNamespaceKeyer(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		objName   string
+		wantKey   string
+	}{
+		{
+			name:      "namespaced",
+			namespace: "test",
+			objName:   "my-object",
+			wantKey:   "test/my-object",
+		},
+		{
+			name:    "cluster scoped",
+			objName: "my-object",
+			wantKey: "my-object",
+		},
+		{
+			name:      "name with dots",
+			namespace: "test",
+			objName:   "my.object",
+			wantKey:   "test/my.object",
+		},
+		{
+			name:      "name with slash",
+			namespace: "test",
+			objName:   "my/object",
+			wantKey:   "test/my%2Fobject",
+		},
+		{
+			name:    "cluster scoped name with slash",
+			objName: "my/object",
+			wantKey: "my%2Fobject",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			key := MetaNamespaceKeyer(tt.namespace, tt.objName)
+			require.Equal(t, tt.wantKey, key)
+
+			namespace, name, err := SplitMetaNamespaceKey(key)
+			require.NoError(t, err)
+			require.Equal(t, tt.namespace, namespace)
+			require.Equal(t, tt.objName, name)
+		})
+	}
+}
+
+func TestSplitMetaNamespaceKeyMalformed(t *testing.T) {
+	for _, key := range []string{"", "test/", "a/b/c", "test/bad%zzescape"} {
+		_, _, err := SplitMetaNamespaceKey(key)
+		require.Error(t, err, key)
+	}
+}
+
+func TestGVRMetaNamespaceKeyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		gvr       schema.GroupVersionResource
+		namespace string
+		objName   string
+		wantKey   string
+	}{
+		{
+			name:      "core group",
+			gvr:       corev1.SchemeGroupVersion.WithResource("secrets"),
+			namespace: "test",
+			objName:   "my-secret",
+			wantKey:   "secrets.v1.::test/my-secret",
+		},
+		{
+			name:      "grouped",
+			gvr:       appsv1.SchemeGroupVersion.WithResource("deployments"),
+			namespace: "test",
+			objName:   "my-deployment",
+			wantKey:   "deployments.v1.apps::test/my-deployment",
+		},
+		{
+			name:      "group with dots",
+			gvr:       schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"},
+			namespace: "test",
+			objName:   "my-cert",
+			wantKey:   "certificates.v1.cert-manager.io::test/my-cert",
+		},
+		{
+			name:    "cluster scoped",
+			gvr:     corev1.SchemeGroupVersion.WithResource("namespaces"),
+			objName: "test",
+			wantKey: "namespaces.v1.::test",
+		},
+		{
+			name:      "name with dots",
+			gvr:       appsv1.SchemeGroupVersion.WithResource("deployments"),
+			namespace: "test",
+			objName:   "my.deployment.v1",
+			wantKey:   "deployments.v1.apps::test/my.deployment.v1",
+		},
+		{
+			name:      "name with slash",
+			gvr:       appsv1.SchemeGroupVersion.WithResource("deployments"),
+			namespace: "test",
+			objName:   "my/deployment",
+			wantKey:   "deployments.v1.apps::test/my%2Fdeployment",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{Namespace: tt.namespace, Name: tt.objName}
+			tombstoneKey, err := cache.MetaNamespaceKeyFunc(obj)
+			require.NoError(t, err)
+			inputs := []any{obj, cache.DeletedFinalStateUnknown{Key: tombstoneKey, Obj: obj}}
+			if !strings.Contains(tt.objName, "/") {
+				// a tombstone without an object is keyed from its unescaped key
+				inputs = append(inputs, cache.DeletedFinalStateUnknown{Key: tombstoneKey})
+			}
+			for _, in := range inputs {
+				key, err := GVRMetaNamespaceKeyFunc(tt.gvr, in)
+				require.NoError(t, err)
+				require.Equal(t, tt.wantKey, key)
+
+				gvr, namespace, name, err := SplitGVRMetaNamespaceKey(key)
+				require.NoError(t, err)
+				require.Equal(t, tt.gvr, *gvr)
+				require.Equal(t, tt.namespace, namespace)
+				require.Equal(t, tt.objName, name)
+			}
+		})
+	}
+}
+
+func TestSplitGVRMetaNamespaceKeyMalformed(t *testing.T) {
+	for _, key := range []string{
+		"",
+		"test/name",
+		"deployments.v1.apps:test/name",
+		"deployments::test/name",
+		".v1.apps::test/name",
+		"deployments..apps::test/name",
+		"deployments.v1.apps::",
+		"deployments.v1.apps::a/b/c",
+	} {
+		_, _, _, err := SplitGVRMetaNamespaceKey(key)
+		require.Error(t, err, key)
+	}
+}
@@ -11,6 +11,9 @@
 // if it doesn't exist and updating it only if the calculated object has
 // changed. It also cleans up duplicate matching component objects by deleting
 // any that match the component selector but do not match the calculated object.
+//
+// `EnsureComponentByPatch` is a variant of `EnsureComponent` that updates
+// existing objects with a minimal JSON merge patch instead of an apply.
 package component
 
 import (
@@ -53,14 +56,20 @@ func NewIndexedComponent[K KubeObject](indexer *typed.Indexer[K], indexName stri
 // List all objects that match the component's specification.
 // Components are expected to be unique (per label), but List returns a slice
 // so that controllers can handle duplicates appropriately.
-func (c *Component[K]) List(ctx context.Context, indexValue fmt.Stringer) (out []K) {
+func (c *Component[K]) List(ctx context.Context, indexValue fmt.Stringer) []K {
+	return c.listByIndex(ctx, c.indexName, indexValue.String())
+}
+
+// listByIndex returns the objects in the named index under indexValue that
+// match the component's selector.
+func (c *Component[K]) listByIndex(ctx context.Context, indexName, indexValue string) (out []K) {
 	out = make([]K, 0)
-	ownedObjects, err := c.indexer.ByIndex(c.indexName, indexValue.String())
+	objects, err := c.indexer.ByIndex(indexName, indexValue)
 	if err != nil {
 		utilruntime.HandleError(err)
 		return
 	}
-	for _, d := range ownedObjects {
+	for _, d := range objects {
 		ls := d.GetLabels()
 		if ls == nil {
 			continue
@@ -79,11 +88,16 @@ type HashableComponent[K KubeObject] struct {
 	*Component[K]
 	hash.ObjectHasher
 	HashAnnotationKey string
+
+	// hashIndexName is the name of an OwnerHashIndexFunc index, if one has
+	// been set with WithHashIndex
+	hashIndexName string
 }
 
 // NewHashableComponent creates HashableComponent from a Component and a
 // hash.ObjectHasher, plus an annotation key to use to store the hash on the
-// object.
+// object. Use hash.WithSortedMergeKeySlices when building the hasher if the
+// order of slices like ports or env vars should not cause an update.
 func NewHashableComponent[K KubeObject](component *Component[K], hasher hash.ObjectHasher, key string) *HashableComponent[K] {
 	return &HashableComponent[K]{
 		Component:         component,
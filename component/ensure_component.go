@@ -2,9 +2,12 @@ package component
 
 import (
 	"context"
+	"sync"
 
+	"golang.org/x/sync/errgroup"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
 
 	"github.com/authzed/controller-idioms/handler"
@@ -28,6 +31,9 @@ type EnsureComponentByHash[K KubeObject, A Annotator[A]] struct {
 	applyObject  func(ctx context.Context, apply A) (K, error)
 	deleteObject func(ctx context.Context, nn types.NamespacedName) error
 	newObj       func(ctx context.Context) A
+
+	// deleteWorkers is the number of extra objects deleted at once
+	deleteWorkers int
 }
 
 var _ handler.ContextHandler = &EnsureComponentByHash[*corev1.Service, *applycorev1.ServiceApplyConfiguration]{}
@@ -48,49 +54,184 @@ func NewEnsureComponentByHash[K KubeObject, A Annotator[A]](
 		applyObject:       applyObj,
 		deleteObject:      deleteObject,
 		newObj:            newObj,
+		deleteWorkers:     1,
 	}
 }
 
-func (e *EnsureComponentByHash[K, A]) Handle(ctx context.Context) {
-	ownedObjs := e.List(ctx, e.nn.MustValue(ctx))
+// WithDeleteConcurrency sets the number of extra objects that are deleted at
+// once. By default, extra objects are deleted one at a time and deletion stops
+// at the first error. With more than one worker, all deletes are attempted and
+// the handler requeues with the aggregated errors if any fail.
+func (e *EnsureComponentByHash[K, A]) WithDeleteConcurrency(workers int) *EnsureComponentByHash[K, A] {
+	if workers < 1 {
+		workers = 1
+	}
+	e.deleteWorkers = workers
+	return e
+}
+
+// ComponentAction is the kind of change EnsureComponentByHash makes to bring
+// the cluster in line with the desired component.
+type ComponentAction string
+
+const (
+	// ComponentActionApply applies the desired object, because no existing
+	// object matches its hash.
+	ComponentActionApply ComponentAction = "Apply"
+	// ComponentActionDelete deletes extra objects, because exactly one
+	// existing object matches the desired hash.
+	ComponentActionDelete ComponentAction = "Delete"
+	// ComponentActionNoOp makes no changes.
+	ComponentActionNoOp ComponentAction = "NoOp"
+)
+
+// Plan describes the change EnsureComponentByHash will make, without making
+// it.
+type Plan[A any] struct {
+	Action ComponentAction
+
+	// Apply is the object (with the hash annotation set) that will be applied
+	// if Action is ComponentActionApply.
+	Apply A
+
+	// Delete is the list of objects that will be deleted if Action is
+	// ComponentActionDelete.
+	Delete []types.NamespacedName
+}
+
+// Plan computes the change that Handle would make for the current context,
+// without calling the apply or delete funcs. If the component has a hash
+// index (see HashableComponent.WithHashIndex), the matching object is looked
+// up in it, and the owner's objects are only listed to find the extra objects
+// to delete once exactly one object matches.
+func (e *EnsureComponentByHash[K, A]) Plan(ctx context.Context) Plan[A] {
+	owner := e.nn.MustValue(ctx)
 
 	newObj := e.newObj(ctx)
 	hash := e.Hash(newObj)
 	newObj = newObj.WithAnnotations(map[string]string{e.HashAnnotationKey: hash})
 
-	matchingObjs := make([]K, 0)
-	extraObjs := make([]K, 0)
-	for _, o := range ownedObjs {
-		annotations := o.GetAnnotations()
-		if annotations == nil {
-			extraObjs = append(extraObjs, o)
-		}
-		if e.Equal(annotations[e.HashAnnotationKey], hash) {
-			matchingObjs = append(matchingObjs, o)
-		} else {
-			extraObjs = append(extraObjs, o)
+	var matchingObjs, extraObjs []K
+	if e.hashIndexName == "" {
+		matchingObjs, extraObjs = e.partitionByHash(e.List(ctx, owner), hash)
+	} else {
+		// with a hash index, the matching objects are found without listing
+		// and comparing all of the owner's objects; those are only listed
+		// when there may be extra objects to delete
+		matchingObjs = e.ListByHash(ctx, owner, hash)
+		if len(matchingObjs) == 1 {
+			extraObjs = exceptObject(e.List(ctx, owner), matchingObjs[0])
 		}
 	}
 
+	// apply if no matching KubeObject in cluster
 	if len(matchingObjs) == 0 {
-		// apply if no matching KubeObject in cluster
-		_, err := e.applyObject(ctx, newObj)
+		return Plan[A]{Action: ComponentActionApply, Apply: newObj}
+	}
+
+	// delete extra objects
+	if len(matchingObjs) == 1 && len(extraObjs) > 0 {
+		return Plan[A]{Action: ComponentActionDelete, Delete: namespacedNames(extraObjs)}
+	}
+
+	return Plan[A]{Action: ComponentActionNoOp}
+}
+
+func (e *EnsureComponentByHash[K, A]) Handle(ctx context.Context) {
+	plan := e.Plan(ctx)
+	switch plan.Action {
+	case ComponentActionApply:
+		_, err := e.applyObject(ctx, plan.Apply)
 		if err != nil {
 			e.ctrls.RequeueErr(ctx, err)
 			return
 		}
+	case ComponentActionDelete:
+		if err := e.deleteAll(ctx, plan.Delete); err != nil {
+			e.ctrls.RequeueErr(ctx, err)
+			return
+		}
+	case ComponentActionNoOp:
 	}
+}
+
+// deleteAll deletes the objects using up to deleteWorkers concurrent calls to
+// deleteObject.
+func (e *EnsureComponentByHash[K, A]) deleteAll(ctx context.Context, nns []types.NamespacedName) error {
+	return deleteAll(ctx, e.deleteObject, e.deleteWorkers, nns)
+}
+
+// partitionByHash splits objs into those whose hash annotation matches hash
+// and those that don't. An object without the hash annotation (for example,
+// because someone edited it and removed it) has drifted and never matches,
+// regardless of how the hasher compares an empty hash.
+func (h *HashableComponent[K]) partitionByHash(objs []K, hash string) (matching, extra []K) {
+	matching = make([]K, 0)
+	extra = make([]K, 0)
+	for _, o := range objs {
+		existing, ok := o.GetAnnotations()[h.HashAnnotationKey]
+		if ok && existing != "" && h.Equal(existing, hash) {
+			matching = append(matching, o)
+		} else {
+			extra = append(extra, o)
+		}
+	}
+	return matching, extra
+}
 
-	if len(matchingObjs) == 1 {
-		// delete extra objects
-		for _, o := range extraObjs {
-			if err := e.deleteObject(ctx, types.NamespacedName{
-				Namespace: o.GetNamespace(),
-				Name:      o.GetName(),
-			}); err != nil {
-				e.ctrls.RequeueErr(ctx, err)
-				return
+// exceptObject returns objs without the object with the same namespace and
+// name as obj.
+func exceptObject[K KubeObject](objs []K, obj K) []K {
+	out := make([]K, 0, len(objs))
+	for _, o := range objs {
+		if o.GetNamespace() == obj.GetNamespace() && o.GetName() == obj.GetName() {
+			continue
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+func namespacedNames[K KubeObject](objs []K) []types.NamespacedName {
+	nns := make([]types.NamespacedName, 0, len(objs))
+	for _, o := range objs {
+		nns = append(nns, types.NamespacedName{
+			Namespace: o.GetNamespace(),
+			Name:      o.GetName(),
+		})
+	}
+	return nns
+}
+
+// deleteAll deletes the objects using up to workers concurrent calls to
+// deleteObject.
+func deleteAll(ctx context.Context, deleteObject func(ctx context.Context, nn types.NamespacedName) error, workers int, nns []types.NamespacedName) error {
+	if workers <= 1 {
+		for _, nn := range nns {
+			if err := deleteObject(ctx, nn); err != nil {
+				return err
 			}
 		}
+		return nil
+	}
+
+	var (
+		g    errgroup.Group
+		mu   sync.Mutex
+		errs []error
+	)
+	g.SetLimit(workers)
+	for _, nn := range nns {
+		nn := nn
+		g.Go(func() error {
+			if err := deleteObject(ctx, nn); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+			return nil
+		})
 	}
+	_ = g.Wait()
+	return utilerrors.NewAggregate(errs)
 }
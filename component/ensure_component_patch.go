@@ -0,0 +1,238 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/typedctx"
+)
+
+// EnsureComponentByPatch is a handler.Handler implementation that ensures a
+// component object exists with the computed spec, like EnsureComponentByHash,
+// but updates existing objects with a JSON merge patch instead of a
+// server-side apply. This is useful when Apply's field manager semantics
+// conflict with other controllers writing to the same object.
+//
+// The hash annotation is used to detect change: a patch is only computed when
+// no owned object matches the hash of the desired object. The patch contains
+// only the fields of the desired object that differ from the current object.
+// Fields that are unset in the desired object are left alone, and lists are
+// replaced wholesale, as with any JSON merge patch.
+//
+// The desired object may have a GenerateName instead of a Name, for
+// components that are identified only by their owner and hash. In that case,
+// a new object is created whenever none matches the hash, stale objects are
+// deleted once one does, and if several match, all but the oldest are
+// deleted.
+type EnsureComponentByPatch[K KubeObject] struct {
+	*HashableComponent[K]
+	ctrls        queue.OperationsContext
+	nn           typedctx.MustValueContext[types.NamespacedName]
+	createObject func(ctx context.Context, obj K) (K, error)
+	patchObject  func(ctx context.Context, nn types.NamespacedName, pt types.PatchType, data []byte) (K, error)
+	deleteObject func(ctx context.Context, nn types.NamespacedName) error
+	newObj       func(ctx context.Context) K
+
+	// deleteWorkers is the number of extra objects deleted at once
+	deleteWorkers int
+}
+
+var _ handler.ContextHandler = &EnsureComponentByPatch[*corev1.Service]{}
+
+// NewEnsureComponentByPatch returns a new EnsureComponentByPatch handler.
+// newObj returns the desired object; createObject is called with it if no
+// owned object with the same name exists (or it has no name), and
+// patchObject is called with a types.MergePatchType patch if one exists but
+// its hash doesn't match.
+func NewEnsureComponentByPatch[K KubeObject](
+	component *HashableComponent[K],
+	owner typedctx.MustValueContext[types.NamespacedName],
+	ctrls queue.OperationsContext,
+	createObject func(ctx context.Context, obj K) (K, error),
+	patchObject func(ctx context.Context, nn types.NamespacedName, pt types.PatchType, data []byte) (K, error),
+	deleteObject func(ctx context.Context, nn types.NamespacedName) error,
+	newObj func(ctx context.Context) K,
+) *EnsureComponentByPatch[K] {
+	return &EnsureComponentByPatch[K]{
+		ctrls:             ctrls,
+		HashableComponent: component,
+		nn:                owner,
+		createObject:      createObject,
+		patchObject:       patchObject,
+		deleteObject:      deleteObject,
+		newObj:            newObj,
+		deleteWorkers:     1,
+	}
+}
+
+// WithDeleteConcurrency sets the number of extra objects that are deleted at
+// once. See EnsureComponentByHash.WithDeleteConcurrency.
+func (e *EnsureComponentByPatch[K]) WithDeleteConcurrency(workers int) *EnsureComponentByPatch[K] {
+	if workers < 1 {
+		workers = 1
+	}
+	e.deleteWorkers = workers
+	return e
+}
+
+const (
+	// ComponentActionCreate creates the desired object, because no existing
+	// object matches its hash or has its name.
+	ComponentActionCreate ComponentAction = "Create"
+	// ComponentActionPatch patches an existing object with the same name as
+	// the desired object, because no existing object matches its hash.
+	ComponentActionPatch ComponentAction = "Patch"
+)
+
+// PatchPlan describes the change EnsureComponentByPatch will make, without
+// making it.
+type PatchPlan[K any] struct {
+	Action ComponentAction
+
+	// Create is the object (with the hash annotation set) that will be
+	// created if Action is ComponentActionCreate.
+	Create K
+
+	// Target is the object that will be patched and Patch is the JSON merge
+	// patch that will be sent if Action is ComponentActionPatch.
+	Target types.NamespacedName
+	Patch  []byte
+
+	// Delete is the list of objects that will be deleted if Action is
+	// ComponentActionDelete.
+	Delete []types.NamespacedName
+}
+
+// Plan computes the change that Handle would make for the current context,
+// without calling the create, patch, or delete funcs.
+func (e *EnsureComponentByPatch[K]) Plan(ctx context.Context) (PatchPlan[K], error) {
+	ownedObjs := e.List(ctx, e.nn.MustValue(ctx))
+
+	newObj := e.newObj(ctx)
+	hash := e.Hash(newObj)
+	annotations := make(map[string]string, len(newObj.GetAnnotations())+1)
+	for k, v := range newObj.GetAnnotations() {
+		annotations[k] = v
+	}
+	annotations[e.HashAnnotationKey] = hash
+	newObj.SetAnnotations(annotations)
+
+	matchingObjs, extraObjs := e.partitionByHash(ownedObjs, hash)
+
+	// objects with generated names are identified only by owner and hash, so
+	// a sync that runs before the cache has seen a newly created object can
+	// create a duplicate; keep the oldest and delete the rest
+	if newObj.GetName() == "" && len(matchingObjs) > 1 {
+		sort.SliceStable(matchingObjs, func(i, j int) bool {
+			ti, tj := matchingObjs[i].GetCreationTimestamp(), matchingObjs[j].GetCreationTimestamp()
+			if !ti.Equal(&tj) {
+				return ti.Before(&tj)
+			}
+			return matchingObjs[i].GetName() < matchingObjs[j].GetName()
+		})
+		extraObjs = append(extraObjs, matchingObjs[1:]...)
+		matchingObjs = matchingObjs[:1]
+	}
+
+	if len(matchingObjs) == 0 {
+		// objects with generated names are never patched, since the desired
+		// object has no name to match
+		for _, o := range extraObjs {
+			if o.GetNamespace() != newObj.GetNamespace() || o.GetName() != newObj.GetName() {
+				continue
+			}
+			patch, err := minimalMergePatch(o, newObj)
+			if err != nil {
+				return PatchPlan[K]{}, err
+			}
+			return PatchPlan[K]{
+				Action: ComponentActionPatch,
+				Target: types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()},
+				Patch:  patch,
+			}, nil
+		}
+		return PatchPlan[K]{Action: ComponentActionCreate, Create: newObj}, nil
+	}
+
+	// delete extra objects
+	if len(matchingObjs) == 1 && len(extraObjs) > 0 {
+		return PatchPlan[K]{Action: ComponentActionDelete, Delete: namespacedNames(extraObjs)}, nil
+	}
+
+	return PatchPlan[K]{Action: ComponentActionNoOp}, nil
+}
+
+func (e *EnsureComponentByPatch[K]) Handle(ctx context.Context) {
+	plan, err := e.Plan(ctx)
+	if err != nil {
+		e.ctrls.RequeueErr(ctx, err)
+		return
+	}
+	switch plan.Action {
+	case ComponentActionCreate:
+		if _, err := e.createObject(ctx, plan.Create); err != nil {
+			e.ctrls.RequeueErr(ctx, err)
+			return
+		}
+	case ComponentActionPatch:
+		if _, err := e.patchObject(ctx, plan.Target, types.MergePatchType, plan.Patch); err != nil {
+			e.ctrls.RequeueErr(ctx, err)
+			return
+		}
+	case ComponentActionDelete:
+		if err := deleteAll(ctx, e.deleteObject, e.deleteWorkers, plan.Delete); err != nil {
+			e.ctrls.RequeueErr(ctx, err)
+			return
+		}
+	case ComponentActionNoOp:
+	}
+}
+
+// minimalMergePatch returns a JSON merge patch that sets the fields of
+// desired on current, containing only the fields that differ.
+func minimalMergePatch(current, desired any) ([]byte, error) {
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	desiredJSON, err := json.Marshal(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	// nulls in the serialized desired object are unset fields, not requests
+	// to remove the field from the current object
+	var desiredFields map[string]any
+	if err := json.Unmarshal(desiredJSON, &desiredFields); err != nil {
+		return nil, err
+	}
+	desiredJSON, err = json.Marshal(dropNulls(desiredFields))
+	if err != nil {
+		return nil, err
+	}
+
+	mergedJSON, err := jsonpatch.MergePatch(currentJSON, desiredJSON)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpatch.CreateMergePatch(currentJSON, mergedJSON)
+}
+
+func dropNulls(fields map[string]any) map[string]any {
+	for k, v := range fields {
+		switch v := v.(type) {
+		case nil:
+			delete(fields, k)
+		case map[string]any:
+			fields[k] = dropNulls(v)
+		}
+	}
+	return fields
+}
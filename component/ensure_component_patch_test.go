@@ -0,0 +1,324 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/authzed/controller-idioms/hash"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/queue/fake"
+	"github.com/authzed/controller-idioms/typedctx"
+)
+
+func TestEnsureServiceByPatchHandler(t *testing.T) {
+	var (
+		hashKey  = "example.com/component-hash"
+		labelSet = map[string]string{"example.com/component": "the-main-service-component"}
+		hasher   = hash.NewObjectHash()
+	)
+	desired := func() *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", Labels: labelSet},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+		}
+	}
+	desiredHash := hasher.Hash(desired())
+
+	tests := []struct {
+		name string
+
+		existingServices []runtime.Object
+
+		expectPlan   ComponentAction
+		expectCreate bool
+		expectPatch  map[string]any
+		expectDelete []types.NamespacedName
+	}{
+		{
+			name:         "creates if no services",
+			expectPlan:   ComponentActionCreate,
+			expectCreate: true,
+		},
+		{
+			name: "creates if the differing service has another name",
+			existingServices: []runtime.Object{&corev1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:        "other",
+				Namespace:   "test",
+				Labels:      labelSet,
+				Annotations: map[string]string{hashKey: "old"},
+			}}},
+			expectPlan:   ComponentActionCreate,
+			expectCreate: true,
+		},
+		{
+			name: "patches only the changed fields if the hash differs",
+			existingServices: []runtime.Object{&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "test",
+					Labels:      labelSet,
+					Annotations: map[string]string{hashKey: "old", "unrelated": "kept"},
+				},
+				Spec: corev1.ServiceSpec{
+					Type:      corev1.ServiceTypeNodePort,
+					ClusterIP: "10.0.0.1",
+				},
+			}},
+			expectPlan: ComponentActionPatch,
+			expectPatch: map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]any{hashKey: desiredHash},
+				},
+				"spec": map[string]any{"type": string(corev1.ServiceTypeClusterIP)},
+			},
+		},
+		{
+			name: "no-ops if the hash matches",
+			existingServices: []runtime.Object{&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "test",
+					Labels:      labelSet,
+					Annotations: map[string]string{hashKey: desiredHash},
+				},
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort},
+			}},
+			expectPlan: ComponentActionNoOp,
+		},
+		{
+			name: "deletes extra services if a matching service exists",
+			existingServices: []runtime.Object{&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "test",
+					Labels:      labelSet,
+					Annotations: map[string]string{hashKey: desiredHash},
+				},
+			}, &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:      "extra",
+				Namespace: "test",
+				Labels:    labelSet,
+			}}},
+			expectPlan:   ComponentActionDelete,
+			expectDelete: []types.NamespacedName{{Namespace: "test", Name: "extra"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ctrls := &fake.FakeInterface{}
+			queueOps := queue.NewQueueOperationsCtx()
+			ctx = queueOps.WithValue(ctx, ctrls)
+
+			indexer, _ := newServiceIndexer(ctx, t, ownerIndexers, tt.existingServices...)
+			ctxOwner := typedctx.WithDefault[types.NamespacedName](testOwner)
+
+			var (
+				created   *corev1.Service
+				patchedNN types.NamespacedName
+				patchType types.PatchType
+				patch     []byte
+				deleted   []types.NamespacedName
+			)
+			ensure := NewEnsureComponentByPatch(
+				NewHashableComponent[*corev1.Service](
+					NewIndexedComponent(
+						indexer,
+						ownerIndex,
+						func(_ context.Context) labels.Selector {
+							return labels.SelectorFromSet(labelSet)
+						}),
+					hasher, hashKey),
+				ctxOwner,
+				queueOps,
+				func(_ context.Context, obj *corev1.Service) (*corev1.Service, error) {
+					created = obj
+					return obj, nil
+				},
+				func(_ context.Context, nn types.NamespacedName, pt types.PatchType, data []byte) (*corev1.Service, error) {
+					patchedNN, patchType, patch = nn, pt, data
+					return nil, nil
+				},
+				func(_ context.Context, nn types.NamespacedName) error {
+					deleted = append(deleted, nn)
+					return nil
+				},
+				func(_ context.Context) *corev1.Service {
+					return desired()
+				})
+
+			plan, err := ensure.Plan(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectPlan, plan.Action)
+
+			ensure.Handle(ctx)
+			require.Zero(t, ctrls.RequeueErrCallCount())
+
+			if tt.expectCreate {
+				require.NotNil(t, created)
+				require.Equal(t, desiredHash, created.GetAnnotations()[hashKey])
+			} else {
+				require.Nil(t, created)
+			}
+
+			if tt.expectPatch != nil {
+				require.Equal(t, types.NamespacedName{Namespace: "test", Name: "test"}, patchedNN)
+				require.Equal(t, types.MergePatchType, patchType)
+				var got map[string]any
+				require.NoError(t, json.Unmarshal(patch, &got))
+				require.Equal(t, tt.expectPatch, got)
+			} else {
+				require.Nil(t, patch, "unexpected patch")
+			}
+
+			require.Equal(t, tt.expectDelete, deleted)
+		})
+	}
+}
+
+func TestEnsureServiceByPatchGenerateName(t *testing.T) {
+	var (
+		hashKey  = "example.com/component-hash"
+		labelSet = map[string]string{"example.com/component": "the-main-service-component"}
+		hasher   = hash.NewObjectHash()
+	)
+	desired := func() *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "test-", Namespace: "test", Labels: labelSet},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+		}
+	}
+	desiredHash := hasher.Hash(desired())
+	generated := func(name, hash string, created time.Time) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			GenerateName:      "test-",
+			Namespace:         "test",
+			Labels:            labelSet,
+			Annotations:       map[string]string{hashKey: hash},
+			CreationTimestamp: metav1.NewTime(created),
+		}}
+	}
+	now := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name string
+
+		existingServices []runtime.Object
+
+		expectPlan   ComponentAction
+		expectCreate bool
+		expectDelete []types.NamespacedName
+	}{
+		{
+			name:         "creates if no services",
+			expectPlan:   ComponentActionCreate,
+			expectCreate: true,
+		},
+		{
+			name:             "creates instead of patching if the hash differs",
+			existingServices: []runtime.Object{generated("test-abcde", "old", now)},
+			expectPlan:       ComponentActionCreate,
+			expectCreate:     true,
+		},
+		{
+			name:             "no-ops if the hash matches",
+			existingServices: []runtime.Object{generated("test-abcde", desiredHash, now)},
+			expectPlan:       ComponentActionNoOp,
+		},
+		{
+			name: "deletes stale services if the hash matches",
+			existingServices: []runtime.Object{
+				generated("test-abcde", "old", now),
+				generated("test-fghij", desiredHash, now),
+			},
+			expectPlan:   ComponentActionDelete,
+			expectDelete: []types.NamespacedName{{Namespace: "test", Name: "test-abcde"}},
+		},
+		{
+			name: "keeps the oldest of several matching services",
+			existingServices: []runtime.Object{
+				generated("test-aaaaa", desiredHash, now),
+				generated("test-bbbbb", desiredHash, now.Add(-time.Minute)),
+				generated("test-ccccc", desiredHash, now.Add(-time.Minute)),
+			},
+			expectPlan: ComponentActionDelete,
+			expectDelete: []types.NamespacedName{
+				{Namespace: "test", Name: "test-ccccc"},
+				{Namespace: "test", Name: "test-aaaaa"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ctrls := &fake.FakeInterface{}
+			queueOps := queue.NewQueueOperationsCtx()
+			ctx = queueOps.WithValue(ctx, ctrls)
+
+			indexer, _ := newServiceIndexer(ctx, t, ownerIndexers, tt.existingServices...)
+			ctxOwner := typedctx.WithDefault[types.NamespacedName](testOwner)
+
+			var (
+				created *corev1.Service
+				deleted []types.NamespacedName
+			)
+			ensure := NewEnsureComponentByPatch(
+				NewHashableComponent[*corev1.Service](
+					NewIndexedComponent(
+						indexer,
+						ownerIndex,
+						func(_ context.Context) labels.Selector {
+							return labels.SelectorFromSet(labelSet)
+						}),
+					hasher, hashKey),
+				ctxOwner,
+				queueOps,
+				func(_ context.Context, obj *corev1.Service) (*corev1.Service, error) {
+					created = obj
+					return obj, nil
+				},
+				func(_ context.Context, _ types.NamespacedName, _ types.PatchType, _ []byte) (*corev1.Service, error) {
+					require.Fail(t, "unexpected patch")
+					return nil, nil
+				},
+				func(_ context.Context, nn types.NamespacedName) error {
+					deleted = append(deleted, nn)
+					return nil
+				},
+				func(_ context.Context) *corev1.Service {
+					return desired()
+				})
+
+			plan, err := ensure.Plan(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectPlan, plan.Action)
+
+			ensure.Handle(ctx)
+			require.Zero(t, ctrls.RequeueErrCallCount())
+
+			if tt.expectCreate {
+				require.NotNil(t, created)
+				require.Empty(t, created.GetName())
+				require.Equal(t, "test-", created.GetGenerateName())
+				require.Equal(t, desiredHash, created.GetAnnotations()[hashKey])
+			} else {
+				require.Nil(t, created)
+			}
+			require.Equal(t, tt.expectDelete, deleted)
+		})
+	}
+}
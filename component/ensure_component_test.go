@@ -2,6 +2,9 @@ package component
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -38,10 +41,38 @@ type MyObjectStatus struct {
 	conditions.StatusConditions `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
 }
 
+// ownerIndex is the name of the index in ownerIndexers.
+const ownerIndex = "owner"
+
+// testOwner is the owner that ownerIndexers indexes every object under.
+var testOwner = types.NamespacedName{Namespace: "test", Name: "owner"}
+
+// ownerIndexers indexes every object as owned by testOwner, for tests that
+// don't depend on how owners are found.
+var ownerIndexers = cache.Indexers{
+	ownerIndex: func(_ interface{}) ([]string, error) {
+		return []string{testOwner.String()}, nil
+	},
+}
+
+// newServiceIndexer starts a service informer with the given indexers over a
+// fake dynamic client that holds objs, and waits for it to sync. The client
+// is returned so that tests can change the objects the informer sees.
+func newServiceIndexer(ctx context.Context, t *testing.T, indexers cache.Indexers, objs ...runtime.Object) (*typed.Indexer[*corev1.Service], *clientfake.FakeDynamicClient) {
+	serviceGVR := corev1.SchemeGroupVersion.WithResource("services")
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := clientfake.NewSimpleDynamicClient(scheme, objs...)
+	informerFactory := dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
+	require.NoError(t, informerFactory.ForResource(serviceGVR).Informer().AddIndexers(indexers))
+	informerFactory.Start(ctx.Done())
+	informerFactory.WaitForCacheSync(ctx.Done())
+	return typed.NewIndexer[*corev1.Service](informerFactory.ForResource(serviceGVR).Informer().GetIndexer()), client
+}
+
 func TestEnsureServiceHandler(t *testing.T) {
 	var (
-		hashKey    = "example.com/component-hash"
-		ownerIndex = "owner"
+		hashKey = "example.com/component-hash"
 	)
 	tests := []struct {
 		name string
@@ -51,10 +82,13 @@ func TestEnsureServiceHandler(t *testing.T) {
 		expectRequeueErr error
 		expectApply      bool
 		expectDelete     bool
+		expectPlan       ComponentAction
+		expectPlanDelete []types.NamespacedName
 	}{
 		{
 			name:        "creates if no services",
 			expectApply: true,
+			expectPlan:  ComponentActionApply,
 		},
 		{
 			name: "creates if no matching services",
@@ -63,6 +97,19 @@ func TestEnsureServiceHandler(t *testing.T) {
 				Namespace: "test",
 			}}},
 			expectApply: true,
+			expectPlan:  ComponentActionApply,
+		},
+		{
+			name: "applies if the matching service is missing the hash annotation",
+			existingServices: []runtime.Object{&corev1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: "test",
+				Labels: map[string]string{
+					"example.com/component": "the-main-service-component",
+				},
+			}}},
+			expectApply: true,
+			expectPlan:  ComponentActionApply,
 		},
 		{
 			name: "no-ops if one matching service",
@@ -82,6 +129,7 @@ func TestEnsureServiceHandler(t *testing.T) {
 					},
 				}},
 			},
+			expectPlan: ComponentActionNoOp,
 		},
 		{
 			name: "deletes extra services if a matching service exists",
@@ -103,7 +151,9 @@ func TestEnsureServiceHandler(t *testing.T) {
 					"example.com/component": "the-main-service-component",
 				},
 			}}},
-			expectDelete: true,
+			expectDelete:     true,
+			expectPlan:       ComponentActionDelete,
+			expectPlanDelete: []types.NamespacedName{{Namespace: "test", Name: "extra"}},
 		},
 	}
 	for _, tt := range tests {
@@ -115,24 +165,11 @@ func TestEnsureServiceHandler(t *testing.T) {
 			applyCalled := false
 			deleteCalled := false
 
-			serviceGVR := corev1.SchemeGroupVersion.WithResource("services")
-
-			scheme := runtime.NewScheme()
-			require.NoError(t, corev1.AddToScheme(scheme))
-			client := clientfake.NewSimpleDynamicClient(scheme, tt.existingServices...)
-			informerFactory := dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
-			require.NoError(t, informerFactory.ForResource(serviceGVR).Informer().AddIndexers(map[string]cache.IndexFunc{
-				ownerIndex: func(_ interface{}) ([]string, error) {
-					return []string{types.NamespacedName{Namespace: "test", Name: "owner"}.String()}, nil
-				},
-			}))
-			informerFactory.Start(ctx.Done())
-			informerFactory.WaitForCacheSync(ctx.Done())
-			indexer := typed.NewIndexer[*corev1.Service](informerFactory.ForResource(serviceGVR).Informer().GetIndexer())
-			ctxOwner := typedctx.WithDefault[types.NamespacedName](types.NamespacedName{Namespace: "test", Name: "owner"})
+			indexer, _ := newServiceIndexer(ctx, t, ownerIndexers, tt.existingServices...)
+			ctxOwner := typedctx.WithDefault[types.NamespacedName](testOwner)
 			queueOps := queue.NewQueueOperationsCtx()
 
-			h := handler.NewHandler(NewEnsureComponentByHash(
+			ensure := NewEnsureComponentByHash(
 				NewHashableComponent[*corev1.Service](
 					NewIndexedComponent(
 						indexer,
@@ -159,9 +196,15 @@ func TestEnsureServiceHandler(t *testing.T) {
 							"example.com/component": "the-main-service-component",
 						}).
 						WithSpec(applycorev1.ServiceSpec().WithType(corev1.ServiceTypeClusterIP))
-				}), "ensureService")
+				})
+
+			plan := ensure.Plan(ctx)
+			require.Equal(t, tt.expectPlan, plan.Action)
+			require.Equal(t, tt.expectPlanDelete, plan.Delete)
+			require.False(t, applyCalled)
+			require.False(t, deleteCalled)
 
-			h.Handle(ctx)
+			handler.NewHandler(ensure, "ensureService").Handle(ctx)
 
 			require.Equal(t, tt.expectApply, applyCalled)
 			require.Equal(t, tt.expectDelete, deleteCalled)
@@ -172,3 +215,157 @@ func TestEnsureServiceHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestEnsureServiceHandlerDeleteConcurrency(t *testing.T) {
+	var (
+		hashKey  = "example.com/component-hash"
+		labelSet = map[string]string{"example.com/component": "the-main-service-component"}
+	)
+	existingServices := []runtime.Object{&corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        "test",
+		Namespace:   "test",
+		Labels:      labelSet,
+		Annotations: map[string]string{hashKey: "n649h58dh598h654hc4hc9hbbh689q"},
+	}}}
+	extras := make([]types.NamespacedName, 0)
+	for i := 0; i < 5; i++ {
+		nn := types.NamespacedName{Namespace: "test", Name: fmt.Sprintf("extra-%d", i)}
+		extras = append(extras, nn)
+		existingServices = append(existingServices, &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:      nn.Name,
+			Namespace: nn.Namespace,
+			Labels:    labelSet,
+		}})
+	}
+
+	tests := []struct {
+		name          string
+		workers       int
+		failOn        string
+		expectRequeue bool
+	}{
+		{
+			name:    "deletes all extras concurrently",
+			workers: 3,
+		},
+		{
+			name:          "requeues if one delete fails",
+			workers:       3,
+			failOn:        "extra-2",
+			expectRequeue: true,
+		},
+		{
+			name:    "non-positive worker count deletes sequentially",
+			workers: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ctrls := &fake.FakeInterface{}
+			queueOps := queue.NewQueueOperationsCtx()
+			ctx = queueOps.WithValue(ctx, ctrls)
+
+			indexer, _ := newServiceIndexer(ctx, t, ownerIndexers, existingServices...)
+			ctxOwner := typedctx.WithDefault[types.NamespacedName](testOwner)
+
+			var mu sync.Mutex
+			deleted := make([]types.NamespacedName, 0)
+			NewEnsureComponentByHash(
+				NewHashableComponent[*corev1.Service](
+					NewIndexedComponent(
+						indexer,
+						ownerIndex,
+						func(_ context.Context) labels.Selector {
+							return labels.SelectorFromSet(labelSet)
+						}),
+					hash.NewObjectHash(), hashKey),
+				ctxOwner,
+				queueOps,
+				func(_ context.Context, _ *applycorev1.ServiceApplyConfiguration) (*corev1.Service, error) {
+					require.Fail(t, "unexpected apply")
+					return nil, nil
+				},
+				func(_ context.Context, nn types.NamespacedName) error {
+					mu.Lock()
+					defer mu.Unlock()
+					deleted = append(deleted, nn)
+					if nn.Name == tt.failOn {
+						return fmt.Errorf("failed to delete %s", nn)
+					}
+					return nil
+				},
+				func(_ context.Context) *applycorev1.ServiceApplyConfiguration {
+					return applycorev1.Service("test", "test").
+						WithLabels(labelSet).
+						WithSpec(applycorev1.ServiceSpec().WithType(corev1.ServiceTypeClusterIP))
+				}).WithDeleteConcurrency(tt.workers).Handle(ctx)
+
+			require.ElementsMatch(t, extras, deleted)
+			if tt.expectRequeue {
+				require.Equal(t, 1, ctrls.RequeueErrCallCount())
+				require.ErrorContains(t, ctrls.RequeueErrArgsForCall(0), tt.failOn)
+			} else {
+				require.Zero(t, ctrls.RequeueErrCallCount())
+			}
+		})
+	}
+}
+
+// prefixHasher treats any hash that is a prefix of another as equal, so that
+// an empty hash would match anything if the handler didn't guard against it.
+type prefixHasher struct {
+	hash.ObjectHasher
+}
+
+func (prefixHasher) Equal(a, b string) bool {
+	return strings.HasPrefix(b, a)
+}
+
+func TestEnsureServiceHandlerMissingHashDrifted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		hashKey  = "example.com/component-hash"
+		labelSet = map[string]string{"example.com/component": "the-main-service-component"}
+	)
+	indexer, _ := newServiceIndexer(ctx, t, ownerIndexers, &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        "test",
+		Namespace:   "test",
+		Labels:      labelSet,
+		Annotations: map[string]string{hashKey: ""},
+	}})
+
+	applyCalled := false
+	ensure := NewEnsureComponentByHash(
+		NewHashableComponent[*corev1.Service](
+			NewIndexedComponent(
+				indexer,
+				ownerIndex,
+				func(_ context.Context) labels.Selector {
+					return labels.SelectorFromSet(labelSet)
+				}),
+			prefixHasher{hash.NewObjectHash()}, hashKey),
+		typedctx.WithDefault[types.NamespacedName](testOwner),
+		queue.NewQueueOperationsCtx(),
+		func(_ context.Context, _ *applycorev1.ServiceApplyConfiguration) (*corev1.Service, error) {
+			applyCalled = true
+			return nil, nil
+		},
+		func(_ context.Context, _ types.NamespacedName) error {
+			require.Fail(t, "unexpected delete")
+			return nil
+		},
+		func(_ context.Context) *applycorev1.ServiceApplyConfiguration {
+			return applycorev1.Service("test", "test").
+				WithLabels(labelSet).
+				WithSpec(applycorev1.ServiceSpec().WithType(corev1.ServiceTypeClusterIP))
+		})
+
+	require.Equal(t, ComponentActionApply, ensure.Plan(ctx).Action)
+	ensure.Handle(ctx)
+	require.True(t, applyCalled)
+}
@@ -0,0 +1,107 @@
+package component
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/typedctx"
+)
+
+// EnsureComponentsByHash is a handler.Handler implementation that ensures a
+// fixed set of component objects exist with their computed specs. It is the
+// multi-object counterpart of EnsureComponentByHash.
+type EnsureComponentsByHash[K KubeObject, A Annotator[A]] struct {
+	*HashableComponent[K]
+	ctrls        queue.OperationsContext
+	nn           typedctx.MustValueContext[types.NamespacedName]
+	applyObject  func(ctx context.Context, apply A) (K, error)
+	deleteObject func(ctx context.Context, nn types.NamespacedName) error
+	newObjs      func(ctx context.Context) []A
+
+	// deleteWorkers is the number of extra objects deleted at once
+	deleteWorkers int
+}
+
+var _ handler.ContextHandler = &EnsureComponentsByHash[*corev1.Service, *applycorev1.ServiceApplyConfiguration]{}
+
+// NewEnsureComponentsByHash returns a new EnsureComponentsByHash handler.
+func NewEnsureComponentsByHash[K KubeObject, A Annotator[A]](
+	component *HashableComponent[K],
+	owner typedctx.MustValueContext[types.NamespacedName],
+	ctrls queue.OperationsContext,
+	applyObj func(ctx context.Context, apply A) (K, error),
+	deleteObject func(ctx context.Context, nn types.NamespacedName) error,
+	newObjs func(ctx context.Context) []A,
+) *EnsureComponentsByHash[K, A] {
+	return &EnsureComponentsByHash[K, A]{
+		ctrls:             ctrls,
+		HashableComponent: component,
+		nn:                owner,
+		applyObject:       applyObj,
+		deleteObject:      deleteObject,
+		newObjs:           newObjs,
+		deleteWorkers:     1,
+	}
+}
+
+// WithDeleteConcurrency sets the number of extra objects that are deleted at
+// once. See EnsureComponentByHash.WithDeleteConcurrency.
+func (e *EnsureComponentsByHash[K, A]) WithDeleteConcurrency(workers int) *EnsureComponentsByHash[K, A] {
+	if workers < 1 {
+		workers = 1
+	}
+	e.deleteWorkers = workers
+	return e
+}
+
+// Handle applies every desired object that has no existing object with a
+// matching hash. Existing objects that match none of the desired hashes are
+// deleted, but only once every desired object has a match in the cluster, so
+// that objects being updated in place are not deleted before the update is
+// observed.
+func (e *EnsureComponentsByHash[K, A]) Handle(ctx context.Context) {
+	ownedObjs := e.List(ctx, e.nn.MustValue(ctx))
+
+	newObjs := e.newObjs(ctx)
+	hashes := make([]string, 0, len(newObjs))
+	for i, o := range newObjs {
+		hash := e.Hash(o)
+		hashes = append(hashes, hash)
+		newObjs[i] = o.WithAnnotations(map[string]string{e.HashAnnotationKey: hash})
+	}
+
+	// an object is extra if it matches none of the desired hashes
+	matched := make([]bool, len(newObjs))
+	extraObjs := ownedObjs
+	for i, hash := range hashes {
+		matchingObjs, _ := e.partitionByHash(ownedObjs, hash)
+		matched[i] = len(matchingObjs) > 0
+		_, extraObjs = e.partitionByHash(extraObjs, hash)
+	}
+
+	// apply any desired objects with no match in the cluster
+	applied := false
+	for i, o := range newObjs {
+		if matched[i] {
+			continue
+		}
+		if _, err := e.applyObject(ctx, o); err != nil {
+			e.ctrls.RequeueErr(ctx, err)
+			return
+		}
+		applied = true
+	}
+	if applied {
+		return
+	}
+
+	// delete extra objects
+	if err := deleteAll(ctx, e.deleteObject, e.deleteWorkers, namespacedNames(extraObjs)); err != nil {
+		e.ctrls.RequeueErr(ctx, err)
+	}
+}
@@ -0,0 +1,263 @@
+package component
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+
+	"github.com/authzed/controller-idioms/hash"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/queue/fake"
+	"github.com/authzed/controller-idioms/typedctx"
+)
+
+func TestEnsureServicesHandler(t *testing.T) {
+	var (
+		hashKey  = "example.com/component-hash"
+		labelSet = map[string]string{"example.com/component": "the-port-service-component"}
+	)
+	desiredService := func(name string, port int32) *applycorev1.ServiceApplyConfiguration {
+		return applycorev1.Service(name, "test").
+			WithLabels(labelSet).
+			WithSpec(applycorev1.ServiceSpec().WithPorts(applycorev1.ServicePort().WithPort(port)))
+	}
+	existingService := func(name string, annotations map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "test",
+			Labels:      labelSet,
+			Annotations: annotations,
+		}}
+	}
+	hasher := hash.NewObjectHash()
+	httpHash := hasher.Hash(desiredService("http", 80))
+	httpsHash := hasher.Hash(desiredService("https", 443))
+
+	tests := []struct {
+		name string
+
+		existingServices []runtime.Object
+		hasher           hash.ObjectHasher
+		deleteWorkers    int
+		failDeletes      []string
+
+		expectApplied []string
+		expectDeleted []types.NamespacedName
+		expectRequeue bool
+	}{
+		{
+			name:          "applies all desired services",
+			expectApplied: []string{"http", "https"},
+		},
+		{
+			name: "applies missing services before deleting extras",
+			existingServices: []runtime.Object{
+				existingService("extra", nil),
+			},
+			expectApplied: []string{"http", "https"},
+		},
+		{
+			name: "applies only the missing service",
+			existingServices: []runtime.Object{
+				existingService("http", map[string]string{hashKey: httpHash}),
+				existingService("extra", nil),
+			},
+			expectApplied: []string{"https"},
+		},
+		{
+			name: "deletes extras once all desired services exist",
+			existingServices: []runtime.Object{
+				existingService("http", map[string]string{hashKey: httpHash}),
+				existingService("https", map[string]string{hashKey: httpsHash}),
+				existingService("extra", map[string]string{hashKey: "stale"}),
+			},
+			expectDeleted: []types.NamespacedName{{Namespace: "test", Name: "extra"}},
+		},
+		{
+			name: "applies over a service whose hash annotation was removed",
+			existingServices: []runtime.Object{
+				existingService("http", map[string]string{hashKey: ""}),
+				existingService("https", map[string]string{hashKey: httpsHash}),
+			},
+			hasher:        prefixHasher{hasher},
+			expectApplied: []string{"http"},
+		},
+		{
+			name: "deletes extras concurrently and requeues with every failure",
+			existingServices: []runtime.Object{
+				existingService("http", map[string]string{hashKey: httpHash}),
+				existingService("https", map[string]string{hashKey: httpsHash}),
+				existingService("extra-0", nil),
+				existingService("extra-1", nil),
+				existingService("extra-2", nil),
+			},
+			deleteWorkers: 3,
+			failDeletes:   []string{"extra-0", "extra-2"},
+			expectDeleted: []types.NamespacedName{
+				{Namespace: "test", Name: "extra-0"},
+				{Namespace: "test", Name: "extra-1"},
+				{Namespace: "test", Name: "extra-2"},
+			},
+			expectRequeue: true,
+		},
+		{
+			name: "no-ops when all desired services exist",
+			existingServices: []runtime.Object{
+				existingService("http", map[string]string{hashKey: httpHash}),
+				existingService("https", map[string]string{hashKey: httpsHash}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ctrls := &fake.FakeInterface{}
+			queueOps := queue.NewQueueOperationsCtx()
+			ctx = queueOps.WithValue(ctx, ctrls)
+
+			indexer, _ := newServiceIndexer(ctx, t, ownerIndexers, tt.existingServices...)
+			ctxOwner := typedctx.WithDefault[types.NamespacedName](testOwner)
+
+			componentHasher := tt.hasher
+			if componentHasher == nil {
+				componentHasher = hasher
+			}
+
+			var mu sync.Mutex
+			applied := make([]string, 0)
+			deleted := make([]types.NamespacedName, 0)
+			NewEnsureComponentsByHash(
+				NewHashableComponent[*corev1.Service](
+					NewIndexedComponent(
+						indexer,
+						ownerIndex,
+						func(_ context.Context) labels.Selector {
+							return labels.SelectorFromSet(labelSet)
+						}),
+					componentHasher, hashKey),
+				ctxOwner,
+				queueOps,
+				func(_ context.Context, apply *applycorev1.ServiceApplyConfiguration) (*corev1.Service, error) {
+					require.NotEmpty(t, apply.Annotations[hashKey])
+					applied = append(applied, *apply.Name)
+					return nil, nil
+				},
+				func(_ context.Context, nn types.NamespacedName) error {
+					mu.Lock()
+					defer mu.Unlock()
+					deleted = append(deleted, nn)
+					for _, name := range tt.failDeletes {
+						if nn.Name == name {
+							return fmt.Errorf("failed to delete %s", nn)
+						}
+					}
+					return nil
+				},
+				func(_ context.Context) []*applycorev1.ServiceApplyConfiguration {
+					return []*applycorev1.ServiceApplyConfiguration{
+						desiredService("http", 80),
+						desiredService("https", 443),
+					}
+				}).WithDeleteConcurrency(tt.deleteWorkers).Handle(ctx)
+
+			require.ElementsMatch(t, tt.expectApplied, applied)
+			require.ElementsMatch(t, tt.expectDeleted, deleted)
+			if tt.expectRequeue {
+				require.Equal(t, 1, ctrls.RequeueErrCallCount())
+				for _, name := range tt.failDeletes {
+					require.ErrorContains(t, ctrls.RequeueErrArgsForCall(0), name)
+				}
+			} else {
+				require.Zero(t, ctrls.RequeueErrCallCount())
+			}
+		})
+	}
+}
+
+func TestEnsureServicesHandlerAppliesThenDeletes(t *testing.T) {
+	var (
+		hashKey  = "example.com/component-hash"
+		labelSet = map[string]string{"example.com/component": "the-port-service-component"}
+		hasher   = hash.NewObjectHash()
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrls := &fake.FakeInterface{}
+	queueOps := queue.NewQueueOperationsCtx()
+	ctx = queueOps.WithValue(ctx, ctrls)
+
+	indexer, client := newServiceIndexer(ctx, t, ownerIndexers, &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:      "extra",
+		Namespace: "test",
+		Labels:    labelSet,
+	}})
+	ctxOwner := typedctx.WithDefault[types.NamespacedName](testOwner)
+
+	var (
+		applied []string
+		deleted []types.NamespacedName
+	)
+	ensure := NewEnsureComponentsByHash(
+		NewHashableComponent[*corev1.Service](
+			NewIndexedComponent(
+				indexer,
+				ownerIndex,
+				func(_ context.Context) labels.Selector {
+					return labels.SelectorFromSet(labelSet)
+				}),
+			hasher, hashKey),
+		ctxOwner,
+		queueOps,
+		func(ctx context.Context, apply *applycorev1.ServiceApplyConfiguration) (*corev1.Service, error) {
+			applied = append(applied, *apply.Name)
+			u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(apply)
+			require.NoError(t, err)
+			_, err = client.Resource(corev1.SchemeGroupVersion.WithResource("services")).Namespace("test").Create(ctx, &unstructured.Unstructured{Object: u}, metav1.CreateOptions{})
+			return nil, err
+		},
+		func(_ context.Context, nn types.NamespacedName) error {
+			deleted = append(deleted, nn)
+			return nil
+		},
+		func(_ context.Context) []*applycorev1.ServiceApplyConfiguration {
+			return []*applycorev1.ServiceApplyConfiguration{
+				applycorev1.Service("http", "test").
+					WithLabels(labelSet).
+					WithSpec(applycorev1.ServiceSpec().WithPorts(applycorev1.ServicePort().WithPort(80))),
+				applycorev1.Service("https", "test").
+					WithLabels(labelSet).
+					WithSpec(applycorev1.ServiceSpec().WithPorts(applycorev1.ServicePort().WithPort(443))),
+			}
+		})
+
+	// the first sync applies the desired services and leaves the extra alone
+	ensure.Handle(ctx)
+	require.ElementsMatch(t, []string{"http", "https"}, applied)
+	require.Empty(t, deleted)
+
+	// wait for the cache to see the applied services
+	require.Eventually(t, func() bool {
+		return len(indexer.List()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the second sync deletes the extra
+	applied = nil
+	ensure.Handle(ctx)
+	require.Empty(t, applied)
+	require.Equal(t, []types.NamespacedName{{Namespace: "test", Name: "extra"}}, deleted)
+	require.Zero(t, ctrls.RequeueErrCallCount())
+}
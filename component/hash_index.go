@@ -0,0 +1,68 @@
+package component
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// OwnerHashIndexFunc returns an index func that indexes objects by the owner
+// keys returned by ownerIndexFunc (i.e. an OwnerReferenceIndexFunc) combined
+// with the value of their hashAnnotationKey annotation, as returned by
+// OwnerHashIndexValue. Objects without the annotation are not indexed.
+//
+// With this index, a HashableComponent can look up the objects for an owner
+// with a given hash directly, instead of listing all of the owner's objects
+// and comparing hashes. See HashableComponent.WithHashIndex.
+func OwnerHashIndexFunc(ownerIndexFunc func(in any) ([]string, error), hashAnnotationKey string) func(in any) ([]string, error) {
+	return func(in any) ([]string, error) {
+		obj := in.(runtime.Object)
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		hash, ok := objMeta.GetAnnotations()[hashAnnotationKey]
+		if !ok || hash == "" {
+			return nil, nil
+		}
+		owners, err := ownerIndexFunc(in)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]string, 0, len(owners))
+		for _, owner := range owners {
+			values = append(values, OwnerHashIndexValue(owner, hash))
+		}
+		return values, nil
+	}
+}
+
+// OwnerHashIndexValue returns the value that OwnerHashIndexFunc indexes
+// objects with the given owner key and hash under.
+func OwnerHashIndexValue(owner, hash string) string {
+	return owner + "#" + hash
+}
+
+// WithHashIndex sets the name of an OwnerHashIndexFunc index, built with the
+// same owner keys as the component's index and with HashAnnotationKey, that
+// ListByHash uses to look up objects by hash.
+func (h *HashableComponent[K]) WithHashIndex(indexName string) *HashableComponent[K] {
+	h.hashIndexName = indexName
+	return h
+}
+
+// ListByHash returns the objects that match the component's specification and
+// whose hash annotation is hash. If a hash index has been set with
+// WithHashIndex, the objects are looked up in it directly (comparing hashes
+// exactly, rather than with the hasher's Equal); otherwise, all of the owner's
+// objects are listed and their hashes compared.
+func (h *HashableComponent[K]) ListByHash(ctx context.Context, indexValue fmt.Stringer, hash string) []K {
+	if h.hashIndexName != "" {
+		return h.listByIndex(ctx, h.hashIndexName, OwnerHashIndexValue(indexValue.String(), hash))
+	}
+	matching, _ := h.partitionByHash(h.List(ctx, indexValue), hash)
+	return matching
+}
@@ -0,0 +1,115 @@
+package component
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/authzed/controller-idioms/hash"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/typedctx"
+)
+
+func TestOwnerHashIndex(t *testing.T) {
+	const (
+		hashIndex  = "ownerHash"
+		ownerLabel = "example.com/owner"
+		hashKey    = "example.com/component-hash"
+	)
+	labelSet := func(owner string) map[string]string {
+		return map[string]string{ownerLabel: owner, "example.com/component": "the-main-service-component"}
+	}
+	service := func(name, owner, hash string) *corev1.Service {
+		s := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test",
+			Labels:    labelSet(owner),
+		}}
+		if hash != "" {
+			s.Annotations = map[string]string{hashKey: hash}
+		}
+		return s
+	}
+
+	desired := func(name string) *applycorev1.ServiceApplyConfiguration {
+		return applycorev1.Service(name, "test").WithLabels(labelSet("b"))
+	}
+	desiredHash := hash.NewObjectHash().Hash(desired("b-desired"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	indexers := cache.Indexers{
+		ownerIndex: OwnerLabelIndexFunc(ownerLabel),
+		hashIndex:  OwnerHashIndexFunc(OwnerLabelIndexFunc(ownerLabel), hashKey),
+	}
+	indexer, _ := newServiceIndexer(ctx, t, indexers,
+		service("a-current", "a", "current"),
+		service("a-old", "a", "old"),
+		service("a-no-hash", "a", ""),
+		service("b-current", "b", "current"),
+		service("b-desired", "b", desiredHash),
+	)
+
+	ownerA := types.NamespacedName{Namespace: "test", Name: "a"}
+	ownerB := types.NamespacedName{Namespace: "test", Name: "b"}
+	require.ElementsMatch(t, []string{
+		OwnerHashIndexValue(ownerA.String(), "current"),
+		OwnerHashIndexValue(ownerA.String(), "old"),
+		OwnerHashIndexValue(ownerB.String(), "current"),
+		OwnerHashIndexValue(ownerB.String(), desiredHash),
+	}, indexer.ListIndexFuncValues(hashIndex))
+
+	newComponent := func() *HashableComponent[*corev1.Service] {
+		return NewHashableComponent(
+			NewIndexedComponent(indexer, ownerIndex, func(_ context.Context) labels.Selector {
+				return labels.SelectorFromSet(map[string]string{"example.com/component": "the-main-service-component"})
+			}),
+			hash.NewObjectHash(), hashKey)
+	}
+	names := func(objs []*corev1.Service) []string {
+		out := make([]string, 0, len(objs))
+		for _, o := range objs {
+			out = append(out, o.GetName())
+		}
+		return out
+	}
+
+	indexed := newComponent().WithHashIndex(hashIndex)
+	scanned := newComponent()
+	for _, c := range []*HashableComponent[*corev1.Service]{indexed, scanned} {
+		require.Equal(t, []string{"a-current"}, names(c.ListByHash(ctx, ownerA, "current")))
+		require.Equal(t, []string{"a-old"}, names(c.ListByHash(ctx, ownerA, "old")))
+		require.Empty(t, c.ListByHash(ctx, ownerA, "missing"))
+		require.Empty(t, c.ListByHash(ctx, ownerA, ""))
+	}
+
+	// with the index, the ensure handler applies when no object has the
+	// desired hash, and otherwise falls back to cleaning up extra objects
+	newEnsure := func(owner types.NamespacedName, name string) *EnsureComponentByHash[*corev1.Service, *applycorev1.ServiceApplyConfiguration] {
+		return NewEnsureComponentByHash(
+			indexed,
+			typedctx.WithDefault[types.NamespacedName](owner),
+			queue.NewQueueOperationsCtx(),
+			func(_ context.Context, _ *applycorev1.ServiceApplyConfiguration) (*corev1.Service, error) {
+				return nil, nil
+			},
+			func(_ context.Context, _ types.NamespacedName) error {
+				return nil
+			},
+			func(_ context.Context) *applycorev1.ServiceApplyConfiguration {
+				return desired(name)
+			})
+	}
+	require.Equal(t, ComponentActionApply, newEnsure(ownerA, "b-desired").Plan(ctx).Action)
+	plan := newEnsure(ownerB, "b-desired").Plan(ctx)
+	require.Equal(t, ComponentActionDelete, plan.Action)
+	require.Equal(t, []types.NamespacedName{{Namespace: "test", Name: "b-current"}}, plan.Delete)
+}
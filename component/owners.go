@@ -0,0 +1,55 @@
+package component
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// OwnerReferenceIndexFunc returns an index func that indexes objects by the
+// namespace/name keys of their owner references of kind `ownerGVK`. The
+// namespace is always set to the namespace of the object passed in, since
+// owner references can't cross namespaces.
+func OwnerReferenceIndexFunc(ownerGVK schema.GroupVersionKind) func(in any) ([]string, error) {
+	apiVersion, kind := ownerGVK.ToAPIVersionAndKind()
+	return func(in any) ([]string, error) {
+		obj := in.(runtime.Object)
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		ownerNames := make([]string, 0)
+		for _, ref := range objMeta.GetOwnerReferences() {
+			if ref.APIVersion != apiVersion || ref.Kind != kind {
+				continue
+			}
+			nn := types.NamespacedName{Name: ref.Name, Namespace: objMeta.GetNamespace()}
+			ownerNames = append(ownerNames, nn.String())
+		}
+
+		return ownerNames, nil
+	}
+}
+
+// OwnerLabelIndexFunc returns an index func that indexes objects by the
+// namespace/name key of the owner named in the `labelKey` label. The
+// namespace is always set to the namespace of the object passed in. Objects
+// without the label are not indexed.
+func OwnerLabelIndexFunc(labelKey string) func(in any) ([]string, error) {
+	return func(in any) ([]string, error) {
+		obj := in.(runtime.Object)
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		ownerName, ok := objMeta.GetLabels()[labelKey]
+		if !ok || ownerName == "" {
+			return nil, nil
+		}
+		nn := types.NamespacedName{Name: ownerName, Namespace: objMeta.GetNamespace()}
+		return []string{nn.String()}, nil
+	}
+}
@@ -0,0 +1,106 @@
+package component
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestOwnerIndexFuncs(t *testing.T) {
+	const (
+		ownerRefIndex   = "ownerRef"
+		ownerLabelIndex = "ownerLabel"
+		ownerLabel      = "example.com/owner"
+	)
+	ownerGVK := appsv1.SchemeGroupVersion.WithKind("Deployment")
+	ownerRef := func(kind, name string) metav1.OwnerReference {
+		return metav1.OwnerReference{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       kind,
+			Name:       name,
+		}
+	}
+	service := func(namespace, name string, refs []metav1.OwnerReference, ls map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			OwnerReferences: refs,
+			Labels:          ls,
+		}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	indexers := cache.Indexers{
+		ownerRefIndex:   OwnerReferenceIndexFunc(ownerGVK),
+		ownerLabelIndex: OwnerLabelIndexFunc(ownerLabel),
+	}
+	indexer, _ := newServiceIndexer(ctx, t, indexers,
+		service("test", "owned-a", []metav1.OwnerReference{ownerRef("Deployment", "a")}, map[string]string{ownerLabel: "a"}),
+		service("test", "owned-a-and-b", []metav1.OwnerReference{ownerRef("Deployment", "a"), ownerRef("Deployment", "b")}, nil),
+		service("other", "owned-a-other-ns", []metav1.OwnerReference{ownerRef("Deployment", "a")}, map[string]string{ownerLabel: "a"}),
+		service("test", "owned-by-replicaset", []metav1.OwnerReference{ownerRef("ReplicaSet", "a")}, nil),
+		service("test", "unowned", nil, map[string]string{"unrelated": "a"}),
+	)
+
+	names := func(svcs []*corev1.Service) []string {
+		out := make([]string, 0, len(svcs))
+		for _, s := range svcs {
+			out = append(out, s.GetNamespace()+"/"+s.GetName())
+		}
+		return out
+	}
+
+	tests := []struct {
+		name      string
+		indexName string
+		owner     types.NamespacedName
+		want      []string
+	}{
+		{
+			name:      "owner reference",
+			indexName: ownerRefIndex,
+			owner:     types.NamespacedName{Namespace: "test", Name: "a"},
+			want:      []string{"test/owned-a", "test/owned-a-and-b"},
+		},
+		{
+			name:      "one of many owner references",
+			indexName: ownerRefIndex,
+			owner:     types.NamespacedName{Namespace: "test", Name: "b"},
+			want:      []string{"test/owned-a-and-b"},
+		},
+		{
+			name:      "owner reference in other namespace",
+			indexName: ownerRefIndex,
+			owner:     types.NamespacedName{Namespace: "other", Name: "a"},
+			want:      []string{"other/owned-a-other-ns"},
+		},
+		{
+			name:      "owner label",
+			indexName: ownerLabelIndex,
+			owner:     types.NamespacedName{Namespace: "test", Name: "a"},
+			want:      []string{"test/owned-a"},
+		},
+		{
+			name:      "no owned objects",
+			indexName: ownerRefIndex,
+			owner:     types.NamespacedName{Namespace: "test", Name: "missing"},
+			want:      []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svcs, err := indexer.ByIndex(tt.indexName, tt.owner.String())
+			require.NoError(t, err)
+			require.ElementsMatch(t, tt.want, names(svcs))
+
+		})
+	}
+}
@@ -2,13 +2,35 @@
 // standard condition accessors.
 // WARNING: this cannot be used with standard kube-ecosystem codegen tooling,
 // which does not yet understand generics. It is mostly useful for tests.
+//
+// WaitForCondition is a handler that works with any type that can find its
+// conditions, including types using the mix-in.
+//
+// SetStatusConditionWithEvent records a kube event when a condition
+// transitions, so that state changes show up in `kubectl describe`.
 package conditions
 
 import (
+	"fmt"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// ConditionTypeReady is the type of the condition built by SummarizeReady
+	ConditionTypeReady = "Ready"
+
+	// ConditionReasonAllTrue is the reason of a summarized Ready condition
+	// when all of the summarized conditions are True
+	ConditionReasonAllTrue = "AllConditionsTrue"
+
+	// ConditionReasonNotAllTrue is the reason of a summarized Ready condition
+	// when any of the summarized conditions are missing or not True
+	ConditionReasonNotAllTrue = "NotAllConditionsTrue"
+)
+
 type StatusConditions struct {
 	// Conditions for the current state of the resource.
 	// +optional
@@ -20,17 +42,30 @@ func (c *StatusConditions) GetStatusConditions() *[]metav1.Condition {
 	return &c.Conditions
 }
 
+// HasConditions is implemented by status types that hold conditions.
+//
+// It is constrained to comparable types so that StatusWithConditions can tell
+// when its Status is unset by comparing it to the zero value. Status structs
+// themselves are not comparable (they hold a slice of conditions), so in
+// practice a HasConditions is a pointer to a status struct, such as
+// *StatusConditions, or an interface.
 type HasConditions interface {
 	comparable
 	GetStatusConditions() *[]metav1.Condition
 }
 
+// StatusWithConditions embeds a status into a resource type and provides
+// condition accessors for it. A nil (zero) Status is treated as having no
+// conditions: the accessors that read conditions report them as missing, and
+// removing conditions is a no-op. Setting conditions requires a non-nil
+// Status.
 type StatusWithConditions[S HasConditions] struct {
 	// +optional
 	Status S `json:"status,omitempty"`
 }
 
-// GetStatusConditions returns all status conditions.
+// GetStatusConditions returns all status conditions, or nil if Status is
+// unset.
 func (s *StatusWithConditions[S]) GetStatusConditions() *[]metav1.Condition {
 	var zero S
 	if s.Status == zero {
@@ -57,9 +92,12 @@ func (s StatusWithConditions[S]) SetStatusCondition(condition metav1.Condition)
 }
 
 // RemoveStatusCondition removes the corresponding conditionType from conditions.
-// conditions must be non-nil.
+// It does nothing if Status is unset.
 func (s StatusWithConditions[S]) RemoveStatusCondition(conditionType string) {
-	meta.RemoveStatusCondition(s.Status.GetStatusConditions(), conditionType)
+	if s.GetStatusConditions() == nil {
+		return
+	}
+	meta.RemoveStatusCondition(s.GetStatusConditions(), conditionType)
 }
 
 // IsStatusConditionTrue returns true when the conditionType is present and set to `metav1.ConditionTrue`
@@ -100,3 +138,73 @@ func (s StatusWithConditions[S]) IsStatusConditionChanged(conditionType string,
 	// if not nil, changed if the message or reason is different
 	return existing.Message != condition.Message || existing.Reason != condition.Reason
 }
+
+// SetStatusConditionIfChanged sets condition and returns true if it differs
+// from the existing condition of the same type by status, reason, or message
+// (or if there is no existing condition). Otherwise, the existing condition is
+// left untouched, including its LastTransitionTime, and it returns false.
+// This can be used to decide whether a status update is needed.
+func (s StatusWithConditions[S]) SetStatusConditionIfChanged(condition metav1.Condition) bool {
+	existing := s.FindStatusCondition(condition.Type)
+	if existing != nil && existing.Status == condition.Status && !s.IsStatusConditionChanged(condition.Type, &condition) {
+		return false
+	}
+	s.SetStatusCondition(condition)
+	return true
+}
+
+// SetStatusConditions sets each of the conditions as with
+// SetStatusConditionIfChanged, and returns true if any of them changed.
+func (s StatusWithConditions[S]) SetStatusConditions(conditions ...metav1.Condition) (changed bool) {
+	for _, condition := range conditions {
+		if s.SetStatusConditionIfChanged(condition) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// RemoveStatusConditions removes the conditions with each of the
+// conditionTypes, and returns true if any of them were present.
+func (s StatusWithConditions[S]) RemoveStatusConditions(conditionTypes ...string) (changed bool) {
+	for _, conditionType := range conditionTypes {
+		if s.FindStatusCondition(conditionType) == nil {
+			continue
+		}
+		s.RemoveStatusCondition(conditionType)
+		changed = true
+	}
+	return changed
+}
+
+// SummarizeReady rolls up the conditions with the given conditionTypes into a
+// single Ready condition. It is True only if all of the conditions are
+// present and True; otherwise it is False, with a message naming the
+// conditions that are missing or not True.
+// The returned condition is not set; pass it to SetStatusCondition (or
+// SetStatusConditionIfChanged) to record it.
+func (s StatusWithConditions[S]) SummarizeReady(conditionTypes ...string) metav1.Condition {
+	failing := make([]string, 0, len(conditionTypes))
+	for _, conditionType := range conditionTypes {
+		condition := s.FindStatusCondition(conditionType)
+		switch {
+		case condition == nil:
+			failing = append(failing, fmt.Sprintf("%s is missing", conditionType))
+		case condition.Status != metav1.ConditionTrue:
+			failing = append(failing, fmt.Sprintf("%s is %s", conditionType, condition.Status))
+		}
+	}
+	if len(failing) == 0 {
+		return metav1.Condition{
+			Type:   ConditionTypeReady,
+			Status: metav1.ConditionTrue,
+			Reason: ConditionReasonAllTrue,
+		}
+	}
+	return metav1.Condition{
+		Type:    ConditionTypeReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  ConditionReasonNotAllTrue,
+		Message: strings.Join(failing, ", "),
+	}
+}
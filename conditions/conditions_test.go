@@ -0,0 +1,197 @@
+package conditions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetStatusConditionIfChanged(t *testing.T) {
+	lastTransition := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	existing := metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionFalse,
+		Reason:             "Waiting",
+		Message:            "waiting for pods",
+		LastTransitionTime: lastTransition,
+	}
+
+	tests := []struct {
+		name      string
+		condition metav1.Condition
+		changed   bool
+	}{
+		{
+			name:      "same",
+			condition: metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse, Reason: "Waiting", Message: "waiting for pods"},
+			changed:   false,
+		},
+		{
+			name:      "status changed",
+			condition: metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Waiting", Message: "waiting for pods"},
+			changed:   true,
+		},
+		{
+			name:      "reason changed",
+			condition: metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse, Reason: "Pending", Message: "waiting for pods"},
+			changed:   true,
+		},
+		{
+			name:      "message changed",
+			condition: metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse, Reason: "Waiting", Message: "waiting for 1 pod"},
+			changed:   true,
+		},
+		{
+			name:      "new type",
+			condition: metav1.Condition{Type: "Degraded", Status: metav1.ConditionFalse, Reason: "Healthy"},
+			changed:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := StatusWithConditions[*StatusConditions]{
+				Status: &StatusConditions{Conditions: []metav1.Condition{existing}},
+			}
+			require.Equal(t, tt.changed, s.SetStatusConditionIfChanged(tt.condition))
+
+			got := s.FindStatusCondition(tt.condition.Type)
+			require.NotNil(t, got)
+			require.Equal(t, tt.condition.Status, got.Status)
+			require.Equal(t, tt.condition.Reason, got.Reason)
+			require.Equal(t, tt.condition.Message, got.Message)
+			if tt.condition.Type == existing.Type && tt.condition.Status == existing.Status {
+				// the transition time only moves when the status changes
+				require.Equal(t, lastTransition, got.LastTransitionTime)
+			}
+			if !tt.changed {
+				require.Equal(t, existing, *got)
+			}
+		})
+	}
+}
+
+func TestSetStatusConditions(t *testing.T) {
+	ready := metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Ready"}
+	degraded := metav1.Condition{Type: "Degraded", Status: metav1.ConditionFalse, Reason: "Healthy"}
+	progressing := metav1.Condition{Type: "Progressing", Status: metav1.ConditionTrue, Reason: "Rolling"}
+
+	s := StatusWithConditions[*StatusConditions]{Status: &StatusConditions{}}
+	require.True(t, s.SetStatusConditions(ready, degraded))
+	require.Len(t, s.Status.Conditions, 2)
+
+	// all unchanged
+	require.False(t, s.SetStatusConditions(ready, degraded))
+
+	// a mix of unchanged and new
+	require.True(t, s.SetStatusConditions(ready, degraded, progressing))
+	require.Len(t, s.Status.Conditions, 3)
+
+	// a mix of unchanged and changed
+	progressing.Reason = "Done"
+	progressing.Status = metav1.ConditionFalse
+	require.True(t, s.SetStatusConditions(ready, progressing))
+	require.True(t, s.IsStatusConditionFalse("Progressing"))
+
+	require.False(t, s.SetStatusConditions())
+}
+
+func TestRemoveStatusConditions(t *testing.T) {
+	s := StatusWithConditions[*StatusConditions]{Status: &StatusConditions{Conditions: []metav1.Condition{
+		{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Ready"},
+		{Type: "Degraded", Status: metav1.ConditionFalse, Reason: "Healthy"},
+	}}}
+
+	require.False(t, s.RemoveStatusConditions("Progressing"))
+	require.Len(t, s.Status.Conditions, 2)
+
+	// a mix of present and missing
+	require.True(t, s.RemoveStatusConditions("Progressing", "Degraded"))
+	require.Len(t, s.Status.Conditions, 1)
+	require.Nil(t, s.FindStatusCondition("Degraded"))
+
+	require.True(t, s.RemoveStatusConditions("Ready"))
+	require.Empty(t, s.Status.Conditions)
+	require.False(t, s.RemoveStatusConditions("Ready"))
+}
+
+func TestSummarizeReady(t *testing.T) {
+	s := StatusWithConditions[*StatusConditions]{Status: &StatusConditions{Conditions: []metav1.Condition{
+		{Type: "Available", Status: metav1.ConditionTrue, Reason: "Available"},
+		{Type: "Migrated", Status: metav1.ConditionTrue, Reason: "Migrated"},
+		{Type: "Healthy", Status: metav1.ConditionFalse, Reason: "Unhealthy"},
+		{Type: "Synced", Status: metav1.ConditionUnknown, Reason: "Syncing"},
+	}}}
+
+	tests := []struct {
+		name  string
+		types []string
+		want  metav1.Condition
+	}{
+		{
+			name:  "all true",
+			types: []string{"Available", "Migrated"},
+			want: metav1.Condition{
+				Type:   ConditionTypeReady,
+				Status: metav1.ConditionTrue,
+				Reason: ConditionReasonAllTrue,
+			},
+		},
+		{
+			name:  "one false",
+			types: []string{"Available", "Healthy", "Migrated"},
+			want: metav1.Condition{
+				Type:    ConditionTypeReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  ConditionReasonNotAllTrue,
+				Message: "Healthy is False",
+			},
+		},
+		{
+			name:  "missing and unknown",
+			types: []string{"Available", "Backed", "Synced"},
+			want: metav1.Condition{
+				Type:    ConditionTypeReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  ConditionReasonNotAllTrue,
+				Message: "Backed is missing, Synced is Unknown",
+			},
+		},
+		{
+			name:  "no types",
+			types: nil,
+			want: metav1.Condition{
+				Type:   ConditionTypeReady,
+				Status: metav1.ConditionTrue,
+				Reason: ConditionReasonAllTrue,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, s.SummarizeReady(tt.types...))
+		})
+	}
+}
+
+func TestGetStatusConditionsPointerStatus(t *testing.T) {
+	var s StatusWithConditions[*StatusConditions]
+
+	// an unset status has no conditions
+	require.Nil(t, s.GetStatusConditions())
+	require.Nil(t, s.FindStatusCondition("Ready"))
+	require.False(t, s.IsStatusConditionTrue("Ready"))
+	require.False(t, s.IsStatusConditionFalse("Ready"))
+	require.False(t, s.IsStatusConditionPresentAndEqual("Ready", metav1.ConditionTrue))
+	require.NotPanics(t, func() { s.RemoveStatusCondition("Ready") })
+	require.False(t, s.RemoveStatusConditions("Ready"))
+
+	// a populated status returns its conditions
+	ready := metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Ready"}
+	s.Status = &StatusConditions{Conditions: []metav1.Condition{ready}}
+	require.NotNil(t, s.GetStatusConditions())
+	require.Equal(t, []metav1.Condition{ready}, *s.GetStatusConditions())
+	require.Same(t, &s.Status.Conditions, s.GetStatusConditions())
+	require.True(t, s.IsStatusConditionTrue("Ready"))
+}
@@ -0,0 +1,34 @@
+package conditions
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// ConditionSetter is a kube object that can set its conditions, such as a
+// resource that embeds StatusWithConditions.
+type ConditionSetter interface {
+	runtime.Object
+	SetStatusConditionIfChanged(condition metav1.Condition) bool
+}
+
+// SetStatusConditionWithEvent sets condition on obj as with
+// SetStatusConditionIfChanged, and records an event of eventtype (i.e.
+// corev1.EventTypeWarning) on obj if the condition transitioned: if it is
+// new, or its status, reason, or message changed. Setting a condition that is
+// already in the same state records nothing, so this can be called on every
+// sync. The event has the condition's reason and a message like
+// "Ready is False: waiting for pods".
+// It returns true if the condition changed.
+func SetStatusConditionWithEvent[K ConditionSetter](recorder record.EventRecorder, obj K, eventtype string, condition metav1.Condition) bool {
+	if !obj.SetStatusConditionIfChanged(condition) {
+		return false
+	}
+	if condition.Message == "" {
+		recorder.Eventf(obj, eventtype, condition.Reason, "%s is %s", condition.Type, condition.Status)
+	} else {
+		recorder.Eventf(obj, eventtype, condition.Reason, "%s is %s: %s", condition.Type, condition.Status, condition.Message)
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package conditions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+type eventObject struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+	StatusWithConditions[*StatusConditions]
+}
+
+func (o *eventObject) DeepCopyObject() runtime.Object {
+	out := *o
+	return &out
+}
+
+func TestSetStatusConditionWithEvent(t *testing.T) {
+	obj := &eventObject{StatusWithConditions: StatusWithConditions[*StatusConditions]{Status: &StatusConditions{}}}
+	recorder := record.NewFakeRecorder(10)
+
+	set := func(status metav1.ConditionStatus, reason, message string) bool {
+		return SetStatusConditionWithEvent(recorder, obj, corev1.EventTypeNormal, metav1.Condition{
+			Type:    "Ready",
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+	}
+	requireEvents := func(expected ...string) {
+		t.Helper()
+		var got []string
+		for len(recorder.Events) > 0 {
+			got = append(got, <-recorder.Events)
+		}
+		require.Equal(t, expected, got)
+	}
+
+	// a new condition is a transition
+	require.True(t, set(metav1.ConditionFalse, "Waiting", "waiting for pods"))
+	requireEvents("Normal Waiting Ready is False: waiting for pods")
+
+	// steady state records nothing
+	require.False(t, set(metav1.ConditionFalse, "Waiting", "waiting for pods"))
+	require.False(t, set(metav1.ConditionFalse, "Waiting", "waiting for pods"))
+	requireEvents()
+
+	// a new status is a transition
+	require.True(t, set(metav1.ConditionTrue, "Running", ""))
+	requireEvents("Normal Running Ready is True")
+	require.True(t, obj.IsStatusConditionTrue("Ready"))
+
+	require.False(t, set(metav1.ConditionTrue, "Running", ""))
+	requireEvents()
+}
@@ -0,0 +1,55 @@
+package conditions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue"
+)
+
+// ConditionFinder is any type that can look up its conditions by type, such
+// as a resource that embeds StatusWithConditions.
+type ConditionFinder interface {
+	FindStatusCondition(conditionType string) *metav1.Condition
+}
+
+// WaitForConditionHandler waits for a condition on another object (i.e. a
+// dependency's Ready condition) to become True before calling Next. Until
+// then, it requeues the current key, with the queue's rate limiting as
+// backoff.
+type WaitForConditionHandler[K ConditionFinder] struct {
+	ctrls         queue.OperationsContext
+	get           func(ctx context.Context) (K, error)
+	conditionType string
+	next          handler.ContextHandler
+}
+
+// WaitForCondition creates a new WaitForConditionHandler that fetches the
+// object to wait on with get.
+func WaitForCondition[K ConditionFinder](ctrls queue.OperationsContext, get func(ctx context.Context) (K, error), conditionType string, next handler.ContextHandler) *WaitForConditionHandler[K] {
+	return &WaitForConditionHandler[K]{
+		ctrls:         ctrls,
+		get:           get,
+		conditionType: conditionType,
+		next:          next,
+	}
+}
+
+func (h *WaitForConditionHandler[K]) Handle(ctx context.Context) {
+	obj, err := h.get(ctx)
+	if err != nil {
+		h.ctrls.RequeueErr(ctx, fmt.Errorf("error fetching object to wait for %s: %w", h.conditionType, err))
+		return
+	}
+	condition := obj.FindStatusCondition(h.conditionType)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		logr.FromContextOrDiscard(ctx).V(4).Info("waiting for condition", "condition", h.conditionType)
+		h.ctrls.Requeue(ctx)
+		return
+	}
+	h.next.Handle(ctx)
+}
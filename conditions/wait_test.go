@@ -0,0 +1,87 @@
+package conditions
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue"
+	queuefake "github.com/authzed/controller-idioms/queue/fake"
+)
+
+type testObject struct {
+	StatusWithConditions[*StatusConditions]
+}
+
+func TestWaitForCondition(t *testing.T) {
+	withReady := func(status metav1.ConditionStatus) *testObject {
+		return &testObject{StatusWithConditions[*StatusConditions]{Status: &StatusConditions{Conditions: []metav1.Condition{
+			{Type: "Ready", Status: status, Reason: "Test"},
+		}}}}
+	}
+
+	tests := []struct {
+		name          string
+		obj           *testObject
+		getErr        error
+		expectNext    bool
+		expectRequeue bool
+		expectErr     bool
+	}{
+		{
+			name:       "ready",
+			obj:        withReady(metav1.ConditionTrue),
+			expectNext: true,
+		},
+		{
+			name:          "not ready",
+			obj:           withReady(metav1.ConditionFalse),
+			expectRequeue: true,
+		},
+		{
+			name:          "unknown",
+			obj:           withReady(metav1.ConditionUnknown),
+			expectRequeue: true,
+		},
+		{
+			name:          "missing condition",
+			obj:           &testObject{StatusWithConditions[*StatusConditions]{Status: &StatusConditions{}}},
+			expectRequeue: true,
+		},
+		{
+			name:      "get error",
+			getErr:    errors.New("not found"),
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrls := &queuefake.FakeInterface{}
+			queueOps := queue.NewQueueOperationsCtx()
+			ctx := queueOps.WithValue(context.Background(), ctrls)
+
+			nextCalled := false
+			h := WaitForCondition(queueOps,
+				func(_ context.Context) (*testObject, error) {
+					return tt.obj, tt.getErr
+				},
+				"Ready",
+				handler.ContextHandlerFunc(func(_ context.Context) {
+					nextCalled = true
+				}),
+			)
+			h.Handle(ctx)
+
+			require.Equal(t, tt.expectNext, nextCalled)
+			require.Equal(t, tt.expectRequeue, ctrls.RequeueCallCount() == 1)
+			require.Equal(t, tt.expectErr, ctrls.RequeueErrCallCount() == 1)
+			if tt.expectErr {
+				require.ErrorIs(t, ctrls.RequeueErrArgsForCall(0), tt.getErr)
+			}
+		})
+	}
+}
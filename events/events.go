@@ -0,0 +1,71 @@
+// Package events provides a typed wrapper for recording kube events.
+//
+// Event reasons are declared once, with NewReason, and then passed around as
+// Reasons rather than strings, so that a misspelled reason is a compile
+// error instead of an event that no alert or dashboard matches. The declared
+// reasons are kept in a registry that can be listed with KnownReasons, i.e.
+// for documentation.
+package events
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reason is the machine-readable reason for an event. Reasons are created
+// with NewReason.
+type Reason struct {
+	name string
+}
+
+// String returns the reason as it appears on events.
+func (r Reason) String() string {
+	return r.name
+}
+
+var (
+	reasonsLock sync.RWMutex
+	reasons     = map[string]struct{}{}
+)
+
+// NewReason declares a Reason and adds it to the registry of known reasons.
+// It is typically used to initialize package-level variables. Declaring the
+// same name twice returns equal Reasons. It panics if name is empty.
+func NewReason(name string) Reason {
+	if name == "" {
+		panic("events: reason must not be empty")
+	}
+	reasonsLock.Lock()
+	defer reasonsLock.Unlock()
+	reasons[name] = struct{}{}
+	return Reason{name: name}
+}
+
+// KnownReasons returns the names of all declared reasons, in sorted order.
+func KnownReasons() []string {
+	reasonsLock.RLock()
+	defer reasonsLock.RUnlock()
+	names := make([]string, 0, len(reasons))
+	for name := range reasons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Eventf records an event of eventtype (i.e. corev1.EventTypeNormal) for
+// obj with recorder, formatting the message with fmt.Sprintf.
+// A zero Reason (one that wasn't created with NewReason) is reported as an
+// error and no event is recorded.
+func Eventf[K runtime.Object](recorder record.EventRecorder, obj K, eventtype string, reason Reason, messageFmt string, args ...any) {
+	if reason.name == "" {
+		utilruntime.HandleError(fmt.Errorf("not recording %s event with empty reason: %s", eventtype, fmt.Sprintf(messageFmt, args...)))
+		return
+	}
+	recorder.Eventf(obj, eventtype, reason.name, messageFmt, args...)
+}
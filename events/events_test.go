@@ -0,0 +1,52 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+var (
+	ReasonSecretAdopted = NewReason("SecretAdopted")
+	ReasonSecretMissing = NewReason("SecretMissing")
+)
+
+func ExampleEventf() {
+	recorder := record.NewFakeRecorder(1)
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "test"}}
+
+	Eventf(recorder, secret, corev1.EventTypeNormal, ReasonSecretAdopted, "Secret was referenced by %s", "test/owner")
+	fmt.Println(<-recorder.Events)
+	// Output: Normal SecretAdopted Secret was referenced by test/owner
+}
+
+func TestEventf(t *testing.T) {
+	recorder := record.NewFakeRecorder(2)
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "test"}}
+
+	Eventf(recorder, secret, corev1.EventTypeNormal, ReasonSecretAdopted, "Secret was referenced by %s", "test/owner")
+	Eventf(recorder, secret, corev1.EventTypeWarning, ReasonSecretMissing, "Secret %s/%s not found", secret.Namespace, secret.Name)
+	// a zero reason doesn't record an event
+	Eventf(recorder, secret, corev1.EventTypeNormal, Reason{}, "ignored")
+	close(recorder.Events)
+
+	events := make([]string, 0)
+	for e := range recorder.Events {
+		events = append(events, e)
+	}
+	require.Equal(t, []string{
+		"Normal SecretAdopted Secret was referenced by test/owner",
+		"Warning SecretMissing Secret test/secret not found",
+	}, events)
+}
+
+func TestNewReason(t *testing.T) {
+	require.Equal(t, "SecretAdopted", ReasonSecretAdopted.String())
+	require.Equal(t, ReasonSecretAdopted, NewReason("SecretAdopted"))
+	require.Subset(t, KnownReasons(), []string{"SecretAdopted", "SecretMissing"})
+	require.Panics(t, func() { NewReason("") })
+}
@@ -1,15 +1,21 @@
 // Package fileinformer implements a kube-style Informer and InformerFactory
 // that can be used to watch files instead of kube apis.
+//
+// By default, the objects passed to event handlers are the names of the
+// watched files. With WithDecoder, the contents of the files are decoded and
+// the resulting objects are passed to handlers instead, and are kept in the
+// informer's store so that they can be read with a Lister.
 package fileinformer
 
 import (
-	"context"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/dynamic/dynamicinformer"
@@ -23,10 +29,27 @@ var FileGroupVersion = schema.GroupVersion{
 	Version: "v1",
 }
 
+// InformerOption configures a FileSharedIndexInformer.
+type InformerOption func(*FileSharedIndexInformer)
+
+// WithDecoder decodes the contents of the watched file with decoder, and
+// passes the decoded object to event handlers in place of the file name.
+// A decoder that produces typed objects can be built from a scheme with
+// serializer.NewCodecFactory(scheme).UniversalDeserializer().
+//
+// Files that fail to decode are logged and skipped. On delete, handlers get
+// the last object that was decoded from the file.
+func WithDecoder(decoder runtime.Decoder) InformerOption {
+	return func(f *FileSharedIndexInformer) {
+		f.decoder = decoder
+	}
+}
+
 // Factory implements dynamicinformer.DynamicSharedInformerFactory, but for
 // starting and managing FileInformers.
 type Factory struct {
-	log logr.Logger
+	log  logr.Logger
+	opts []InformerOption
 	sync.Mutex
 	informers map[schema.GroupVersionResource]informers.GenericInformer
 	// startedInformers is used for tracking which informers have been started.
@@ -36,10 +59,12 @@ type Factory struct {
 
 var _ dynamicinformer.DynamicSharedInformerFactory = &Factory{}
 
-// NewFileInformerFactory creates a new Factory.
-func NewFileInformerFactory(log logr.Logger) (*Factory, error) {
+// NewFileInformerFactory creates a new Factory. The options are applied to
+// each informer that the factory creates.
+func NewFileInformerFactory(log logr.Logger, opts ...InformerOption) (*Factory, error) {
 	return &Factory{
 		log:              log,
+		opts:             opts,
 		informers:        make(map[schema.GroupVersionResource]informers.GenericInformer),
 		startedInformers: make(map[schema.GroupVersionResource]bool),
 	}, nil
@@ -76,7 +101,7 @@ func (f *Factory) ForResource(gvr schema.GroupVersionResource) informers.Generic
 	if err != nil {
 		panic(err)
 	}
-	informer, err = NewFileInformer(f.log, watcher, gvr)
+	informer, err = NewFileInformer(f.log, watcher, gvr, f.opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -118,12 +143,12 @@ type FileInformer struct {
 var _ informers.GenericInformer = &FileInformer{}
 
 // NewFileInformer returns a new FileInformer.
-func NewFileInformer(log logr.Logger, watcher *fsnotify.Watcher, gvr schema.GroupVersionResource) (*FileInformer, error) {
+func NewFileInformer(log logr.Logger, watcher *fsnotify.Watcher, gvr schema.GroupVersionResource, opts ...InformerOption) (*FileInformer, error) {
 	return &FileInformer{
 		log:      log,
 		fileName: gvr.Resource,
 		watcher:  watcher,
-		informer: NewFileSharedIndexInformer(log, gvr.Resource, watcher, 1*time.Minute),
+		informer: NewFileSharedIndexInformer(log, gvr.Resource, watcher, 1*time.Minute, opts...),
 	}, nil
 }
 
@@ -131,9 +156,10 @@ func (f *FileInformer) Informer() cache.SharedIndexInformer {
 	return f.informer
 }
 
+// Lister returns a lister for the object decoded from the file. The lister
+// is only populated if the informer was created WithDecoder.
 func (f *FileInformer) Lister() cache.GenericLister {
-	// TODO implement me
-	panic("implement me")
+	return cache.NewGenericLister(f.informer.GetIndexer(), FileGroupVersion.WithResource(f.fileName).GroupResource())
 }
 
 type FileSharedIndexInformer struct {
@@ -145,20 +171,144 @@ type FileSharedIndexInformer struct {
 	watcher                         *fsnotify.Watcher
 	started                         bool
 	synced                          bool
-	handlers                        []cache.ResourceEventHandler
+	handlers                        []fileEventHandler
+
+	// decoder, if set, decodes the file into the object passed to handlers
+	decoder runtime.Decoder
+	// last is the last object passed to handlers for the file
+	last any
+	// indexer holds the last decoded object, if there is a decoder
+	indexer cache.Indexer
 }
 
 var _ cache.SharedIndexInformer = (*FileSharedIndexInformer)(nil)
 
-// NewFileSharedIndexInformer creates a new informer watching the file
-// Note that currently all event handlers share the default resync period.
-func NewFileSharedIndexInformer(log logr.Logger, fileName string, watcher *fsnotify.Watcher, defaultEventHandlerResyncPeriod time.Duration) *FileSharedIndexInformer {
-	return &FileSharedIndexInformer{
+// fileEventHandler is a handler along with the period at which it is resynced
+type fileEventHandler struct {
+	cache.ResourceEventHandler
+	resyncPeriod time.Duration
+}
+
+// NewFileSharedIndexInformer creates a new informer watching the file.
+// Handlers added with AddEventHandler are resynced every
+// defaultEventHandlerResyncPeriod.
+func NewFileSharedIndexInformer(log logr.Logger, fileName string, watcher *fsnotify.Watcher, defaultEventHandlerResyncPeriod time.Duration, opts ...InformerOption) *FileSharedIndexInformer {
+	f := &FileSharedIndexInformer{
 		log:                             log.WithValues("file", fileName),
 		fileName:                        fileName,
 		watcher:                         watcher,
-		handlers:                        []cache.ResourceEventHandler{},
+		handlers:                        []fileEventHandler{},
 		defaultEventHandlerResyncPeriod: defaultEventHandlerResyncPeriod,
+		indexer:                         cache.NewIndexer(cache.DeletionHandlingMetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+	}
+	for _, o := range opts {
+		o(f)
+	}
+	return f
+}
+
+// readObject returns the object to pass to handlers for the file: the file
+// name, or the decoded contents of the file if there is a decoder.
+func (f *FileSharedIndexInformer) readObject() (any, error) {
+	if f.decoder == nil {
+		return f.fileName, nil
+	}
+	data, err := os.ReadFile(f.fileName)
+	if err != nil {
+		return nil, err
+	}
+	obj, _, err := f.decoder.Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding file: %w", err)
+	}
+	return obj, nil
+}
+
+// setLast records obj as the current object for the file, replacing the
+// contents of the store. It must be called with the lock held.
+func (f *FileSharedIndexInformer) setLast(obj any) {
+	f.last = obj
+	if f.decoder == nil {
+		return
+	}
+	var objs []any
+	if obj != nil {
+		objs = append(objs, obj)
+	}
+	utilruntime.HandleError(f.indexer.Replace(objs, ""))
+}
+
+// notifyAdd reads the file and calls OnAdd for each handler
+func (f *FileSharedIndexInformer) notifyAdd(isInInitialList bool) {
+	obj, err := f.readObject()
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	f.Lock()
+	f.setLast(obj)
+	f.Unlock()
+
+	f.RLock()
+	defer f.RUnlock()
+	for _, h := range f.handlers {
+		h.OnAdd(obj, isInInitialList)
+	}
+}
+
+// notifyUpdate reads the file again and calls OnUpdate for each handler,
+// with the last object as the old object.
+func (f *FileSharedIndexInformer) notifyUpdate() {
+	obj, err := f.readObject()
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	f.Lock()
+	oldObj := f.last
+	if oldObj == nil {
+		oldObj = obj
+	}
+	f.setLast(obj)
+	f.Unlock()
+
+	f.RLock()
+	defer f.RUnlock()
+	for _, h := range f.handlers {
+		h.OnUpdate(oldObj, obj)
+	}
+}
+
+// notifyResync calls OnUpdate with the last object as both the old and new
+// object for each of the handlers at the given indexes.
+func (f *FileSharedIndexInformer) notifyResync(due []int) {
+	f.RLock()
+	defer f.RUnlock()
+	if f.last == nil {
+		return
+	}
+	for _, i := range due {
+		f.handlers[i].OnUpdate(f.last, f.last)
+	}
+}
+
+// notifyDelete calls OnDelete for each handler with the last object
+func (f *FileSharedIndexInformer) notifyDelete() {
+	f.Lock()
+	obj := f.last
+	f.setLast(nil)
+	f.Unlock()
+	if obj == nil {
+		obj = cache.DeletedFinalStateUnknown{Key: f.fileName}
+		if f.decoder == nil {
+			obj = f.fileName
+		}
+	}
+
+	f.RLock()
+	defer f.RUnlock()
+	for _, h := range f.handlers {
+		h.OnDelete(obj)
 	}
 }
 
@@ -168,7 +318,10 @@ func (f *FileSharedIndexInformer) AddEventHandler(handler cache.ResourceEventHan
 	return f.AddEventHandlerWithResyncPeriod(handler, f.defaultEventHandlerResyncPeriod)
 }
 
-func (f *FileSharedIndexInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, _ time.Duration) (cache.ResourceEventHandlerRegistration, error) {
+// AddEventHandlerWithResyncPeriod adds a handler that is resynced every
+// resyncPeriod, independently of the other handlers. A resyncPeriod of 0
+// disables resyncs for the handler.
+func (f *FileSharedIndexInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, resyncPeriod time.Duration) (cache.ResourceEventHandlerRegistration, error) {
 	f.RLock()
 	if f.started {
 		panic("cannot add event handlers after informer has started")
@@ -176,8 +329,10 @@ func (f *FileSharedIndexInformer) AddEventHandlerWithResyncPeriod(handler cache.
 	f.RUnlock()
 	f.Lock()
 	defer f.Unlock()
-	f.handlers = append(f.handlers, handler)
-	// TODO: non-default resync period
+	f.handlers = append(f.handlers, fileEventHandler{
+		ResourceEventHandler: handler,
+		resyncPeriod:         resyncPeriod,
+	})
 
 	return nil, nil
 }
@@ -188,9 +343,10 @@ func (*FileSharedIndexInformer) RemoveEventHandler(_ cache.ResourceEventHandlerR
 	panic("unimplemented")
 }
 
+// GetStore returns the store holding the object decoded from the file.
+// It is always empty if the informer has no decoder.
 func (f *FileSharedIndexInformer) GetStore() cache.Store {
-	// TODO implement me
-	panic("implement me")
+	return f.indexer
 }
 
 func (f *FileSharedIndexInformer) GetController() cache.Controller {
@@ -213,11 +369,7 @@ func (f *FileSharedIndexInformer) Run(stopCh <-chan struct{}) {
 		}
 
 		// do an initial read
-		f.RLock()
-		for _, h := range f.handlers {
-			h.OnAdd(fileName, true)
-		}
-		f.RUnlock()
+		f.notifyAdd(true)
 
 		f.Lock()
 		f.synced = true
@@ -231,21 +383,16 @@ func (f *FileSharedIndexInformer) Run(stopCh <-chan struct{}) {
 				utilruntime.HandleError(f.watcher.Close())
 				f.log.V(4).Info("stopped watching")
 			}()
-			ctx, cancel := context.WithTimeout(context.Background(), f.defaultEventHandlerResyncPeriod)
+			resyncs := newResyncSchedule(f.resyncPeriods(), time.Now())
+			defer resyncs.stop()
 			for {
 				select {
-				case <-ctx.Done():
-					f.log.V(4).Info("resyncing file", "after", f.defaultEventHandlerResyncPeriod.String())
-					f.RLock()
-					for _, h := range f.handlers {
-						h.OnUpdate(fileName, fileName)
-					}
-					f.RUnlock()
-					cancel()
-					ctx, cancel = context.WithTimeout(context.Background(), f.defaultEventHandlerResyncPeriod)
+				case now := <-resyncs.C():
+					due := resyncs.due(now)
+					f.log.V(4).Info("resyncing file", "handlers", len(due))
+					f.notifyResync(due)
 				case event, ok := <-f.watcher.Events:
 					if !ok {
-						cancel()
 						return
 					}
 					f.log.V(8).Info("filewatcher got event", "event", event.String(), "event_name", event.Name)
@@ -254,37 +401,25 @@ func (f *FileSharedIndexInformer) Run(stopCh <-chan struct{}) {
 					}
 					f.log.V(4).Info("filewatcher got event", "event", event.String(), "event_name", event.Name)
 					if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
-						f.RLock()
-						for _, h := range f.handlers {
-							h.OnAdd(fileName, false)
-						}
-						f.RUnlock()
+						f.notifyAdd(false)
 					}
 					// chmod is the event from a configmap reload in kube
 					if event.Has(fsnotify.Rename) || event.Has(fsnotify.Chmod) {
-						f.RLock()
-						for _, h := range f.handlers {
-							h.OnUpdate(fileName, fileName)
-						}
-						f.RUnlock()
+						f.notifyUpdate()
 					}
 					if event.Has(fsnotify.Remove) {
-						f.RLock()
-						for _, h := range f.handlers {
-							h.OnDelete(fileName)
-						}
+						f.notifyDelete()
 						// attempt to re-add the watch
+						f.RLock()
 						utilruntime.HandleError(f.watcher.Add(event.Name))
 						f.RUnlock()
 					}
 				case err, ok := <-f.watcher.Errors:
 					if !ok {
-						cancel()
 						return
 					}
 					utilruntime.HandleError(fmt.Errorf("error watching file: %w", err))
 				case <-stopCh:
-					cancel()
 					return
 				}
 			}
@@ -292,6 +427,86 @@ func (f *FileSharedIndexInformer) Run(stopCh <-chan struct{}) {
 	})
 }
 
+// resyncPeriods returns the resync period of each handler, by index
+func (f *FileSharedIndexInformer) resyncPeriods() []time.Duration {
+	f.RLock()
+	defer f.RUnlock()
+	periods := make([]time.Duration, 0, len(f.handlers))
+	for _, h := range f.handlers {
+		periods = append(periods, h.resyncPeriod)
+	}
+	return periods
+}
+
+// resyncSchedule tracks when each handler is next due for a resync, and
+// keeps a timer that fires when the earliest one is due.
+type resyncSchedule struct {
+	periods []time.Duration
+	next    []time.Time
+	timer   *time.Timer
+}
+
+func newResyncSchedule(periods []time.Duration, now time.Time) *resyncSchedule {
+	s := &resyncSchedule{
+		periods: periods,
+		next:    make([]time.Time, len(periods)),
+	}
+	for i, period := range periods {
+		if period > 0 {
+			s.next[i] = now.Add(period)
+		}
+	}
+	s.reset()
+	return s
+}
+
+// C returns the channel that fires when a resync is due, or nil (which
+// blocks forever) if no handler is resynced.
+func (s *resyncSchedule) C() <-chan time.Time {
+	if s.timer == nil {
+		return nil
+	}
+	return s.timer.C
+}
+
+// due returns the indexes of the handlers that are due for a resync at now,
+// and schedules their next resyncs.
+func (s *resyncSchedule) due(now time.Time) []int {
+	var due []int
+	for i, next := range s.next {
+		if next.IsZero() || now.Before(next) {
+			continue
+		}
+		due = append(due, i)
+		s.next[i] = now.Add(s.periods[i])
+	}
+	s.reset()
+	return due
+}
+
+func (s *resyncSchedule) reset() {
+	var earliest time.Time
+	for _, next := range s.next {
+		if !next.IsZero() && (earliest.IsZero() || next.Before(earliest)) {
+			earliest = next
+		}
+	}
+	if earliest.IsZero() {
+		return
+	}
+	if s.timer == nil {
+		s.timer = time.NewTimer(time.Until(earliest))
+		return
+	}
+	s.timer.Reset(time.Until(earliest))
+}
+
+func (s *resyncSchedule) stop() {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
+
 func (f *FileSharedIndexInformer) HasSynced() bool {
 	f.RLock()
 	defer f.RUnlock()
@@ -311,14 +526,14 @@ func (f *FileSharedIndexInformer) SetWatchErrorHandler(_ cache.WatchErrorHandler
 	panic("implement me")
 }
 
-func (f *FileSharedIndexInformer) AddIndexers(_ cache.Indexers) error {
-	// TODO implement me
-	panic("implement me")
+func (f *FileSharedIndexInformer) AddIndexers(indexers cache.Indexers) error {
+	return f.indexer.AddIndexers(indexers)
 }
 
+// GetIndexer returns the indexer holding the object decoded from the file.
+// It is always empty if the informer has no decoder.
 func (f *FileSharedIndexInformer) GetIndexer() cache.Indexer {
-	// TODO implement me
-	panic("implement me")
+	return f.indexer
 }
 
 func (f *FileSharedIndexInformer) SetTransform(_ cache.TransformFunc) error {
@@ -7,8 +7,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2/klogr"
 )
@@ -93,6 +97,146 @@ func TestFileInformer(t *testing.T) {
 	eventHandlers2.AssertExpectations(t)
 }
 
+func TestFileInformerWithDecoder(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+
+	informerFactory, err := NewFileInformerFactory(klogr.New(), WithDecoder(decoder))
+	require.NoError(t, err)
+
+	file, err := os.CreateTemp("", "watched-file")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	require.NoError(t, file.Close())
+	writeConfigMap := func(value string) {
+		require.NoError(t, os.WriteFile(file.Name(), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+data:
+  key: `+value+`
+`), 0o600))
+	}
+	writeConfigMap("initial")
+
+	var (
+		lock    sync.Mutex
+		added   *corev1.ConfigMap
+		updated [2]*corev1.ConfigMap
+		deleted *corev1.ConfigMap
+	)
+	inf := informerFactory.ForResource(FileGroupVersion.WithResource(file.Name())).Informer()
+	_, err = inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			lock.Lock()
+			defer lock.Unlock()
+			added = obj.(*corev1.ConfigMap)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			lock.Lock()
+			defer lock.Unlock()
+			updated = [2]*corev1.ConfigMap{oldObj.(*corev1.ConfigMap), newObj.(*corev1.ConfigMap)}
+		},
+		DeleteFunc: func(obj interface{}) {
+			lock.Lock()
+			defer lock.Unlock()
+			deleted = obj.(*corev1.ConfigMap)
+		},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	informerFactory.Start(ctx.Done())
+	informerFactory.WaitForCacheSync(ctx.Done())
+
+	// the initial add delivers the decoded object
+	lock.Lock()
+	require.NotNil(t, added)
+	require.Equal(t, "test", added.Name)
+	require.Equal(t, "initial", added.Data["key"])
+	lock.Unlock()
+
+	// a rewrite followed by a chmod (as in a configmap reload) delivers an
+	// update from the last decoded object to the new contents
+	writeConfigMap("changed")
+	require.NoError(t, os.Chmod(file.Name(), 0o640))
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return updated[1] != nil && updated[1].Data["key"] == "changed"
+	}, 500*time.Millisecond, 10*time.Millisecond)
+
+	// a delete delivers the last decoded object
+	require.NoError(t, os.Remove(file.Name()))
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return deleted != nil && deleted.Data["key"] == "changed"
+	}, 500*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestFileInformerResyncPeriodPerHandler(t *testing.T) {
+	file, err := os.CreateTemp("", "watched-file")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	require.NoError(t, file.Close())
+
+	watcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	inf := NewFileSharedIndexInformer(klogr.New(), file.Name(), watcher, time.Hour)
+
+	var (
+		lock       sync.Mutex
+		resyncs    = map[string]int{}
+		resyncFunc = func(name string) cache.ResourceEventHandler {
+			return cache.ResourceEventHandlerFuncs{
+				UpdateFunc: func(_, _ interface{}) {
+					lock.Lock()
+					defer lock.Unlock()
+					resyncs[name]++
+				},
+			}
+		}
+	)
+	_, err = inf.AddEventHandlerWithResyncPeriod(resyncFunc("fast"), 20*time.Millisecond)
+	require.NoError(t, err)
+	_, err = inf.AddEventHandlerWithResyncPeriod(resyncFunc("slow"), 300*time.Millisecond)
+	require.NoError(t, err)
+	_, err = inf.AddEventHandlerWithResyncPeriod(resyncFunc("never"), 0)
+	require.NoError(t, err)
+	_, err = inf.AddEventHandler(resyncFunc("default"))
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go inf.Run(stopCh)
+
+	// the fast handler is resynced several times before the slow one is
+	// resynced at all
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return resyncs["fast"] >= 5
+	}, time.Second, 10*time.Millisecond)
+	lock.Lock()
+	require.Zero(t, resyncs["slow"])
+	lock.Unlock()
+
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return resyncs["slow"] >= 1
+	}, time.Second, 10*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Greater(t, resyncs["fast"], 5*resyncs["slow"])
+	require.Zero(t, resyncs["never"])
+	require.Zero(t, resyncs["default"])
+}
+
 type MockEventHandlers struct {
 	mock.Mock
 	sync.Mutex
@@ -0,0 +1,30 @@
+package fileinformer
+
+import (
+	"github.com/go-logr/logr"
+
+	"github.com/authzed/controller-idioms/typed"
+)
+
+// NewRegisteredFileInformerFactory creates a new Factory and registers it in
+// registry under the given FactoryKey. Informers for individual files can
+// then be fetched from the registry with the keys from RegistryKeyForFile.
+//
+// Use WithDecoder to make the decoded objects available through the
+// registry's listers and indexers.
+func NewRegisteredFileInformerFactory(registry *typed.Registry, key typed.FactoryKey, log logr.Logger, opts ...InformerOption) (*Factory, error) {
+	factory, err := NewFileInformerFactory(log, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := registry.Add(key, factory); err != nil {
+		return nil, err
+	}
+	return factory, nil
+}
+
+// RegistryKeyForFile returns the RegistryKey for the informer watching
+// fileName in the factory registered under key.
+func RegistryKeyForFile(key typed.FactoryKey, fileName string) typed.RegistryKey {
+	return typed.NewRegistryKey(key, FileGroupVersion.WithResource(fileName))
+}
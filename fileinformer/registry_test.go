@@ -0,0 +1,64 @@
+package fileinformer
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/authzed/controller-idioms/typed"
+)
+
+func TestNewRegisteredFileInformerFactory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+
+	file, err := os.CreateTemp("", "watched-file")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  key: value
+`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	registry := typed.NewRegistry()
+	factoryKey := typed.FactoryKey("files")
+	_, err = NewRegisteredFileInformerFactory(registry, factoryKey, klogr.New(), WithDecoder(decoder))
+	require.NoError(t, err)
+
+	// registering a second factory under the same key fails
+	_, err = NewRegisteredFileInformerFactory(registry, factoryKey, klogr.New())
+	require.Error(t, err)
+
+	key := RegistryKeyForFile(factoryKey, file.Name())
+	inf := registry.MustInformerForKey(key)
+	require.IsType(t, &FileSharedIndexInformer{}, inf)
+
+	started, err := registry.EnsureStarted(key, ctx.Done())
+	require.NoError(t, err)
+	require.Same(t, inf, started)
+
+	cm, err := typed.MustListerForKey[*corev1.ConfigMap](registry, key).Get("config")
+	require.NoError(t, err)
+	require.Equal(t, "value", cm.Data["key"])
+
+	cms, err := typed.ListTyped[*corev1.ConfigMap](registry, key, metav1.NamespaceAll, labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, cms, 1)
+}
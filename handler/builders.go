@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+)
+
+// Builders is a list of Builder with methods that compose the list into a
+// single Builder. The methods can be nested to build a reconcile graph
+// declaratively, i.e.:
+//
+//	Builders{
+//		validate,
+//		Builders{fetchA, fetchB}.Parallel(),
+//		Builders{create, update}.Switch(selectAction),
+//	}.Chain()
+type Builders []Builder
+
+// Chain composes the builders with Chain: each one is built with the next as
+// its next handler.
+func (b Builders) Chain() Builder {
+	return Chain(b...)
+}
+
+// Parallel composes the builders with Parallel: they run concurrently, and
+// the next handler runs once all of them have returned.
+func (b Builders) Parallel() Builder {
+	return Parallel(b...)
+}
+
+// Sequence composes the builders with Sequence: each one is built with a
+// NoopHandler as its next handler, and they run one after another with the
+// same context, followed by the next handler. As with Sequence, it stops
+// early once the context is done (i.e. after a requeue).
+func (b Builders) Sequence() Builder {
+	return func(next ...Handler) Handler {
+		handlers := make([]Handler, 0, len(b)+1)
+		for _, child := range b {
+			handlers = append(handlers, child(NoopHandler))
+		}
+		seq := Sequence(handlers...)
+		nextHandler := Handlers(next).MustOne()
+		return NewHandler(ContextHandlerFunc(func(ctx context.Context) {
+			seq.Handle(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			nextHandler.Handle(ctx)
+		}), seq.ID())
+	}
+}
+
+// Switch composes the builders with Switch: each one is built with the next
+// handler as its next handler, and selector picks which one runs by the ID
+// of the Handler it builds.
+func (b Builders) Switch(selector func(ctx context.Context) Key) Builder {
+	return func(next ...Handler) Handler {
+		handlers := make([]Handler, 0, len(b))
+		for _, child := range b {
+			handlers = append(handlers, child(next...))
+		}
+		return Switch(selector, handlers...)
+	}
+}
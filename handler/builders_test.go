@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilders(t *testing.T) {
+	var (
+		lock sync.Mutex
+		ran  []string
+	)
+	record := func(id string) {
+		lock.Lock()
+		defer lock.Unlock()
+		ran = append(ran, id)
+	}
+	// step builds a handler that records its id and then calls next
+	step := func(id string) Builder {
+		return func(next ...Handler) Handler {
+			return NewHandlerFromFunc(func(ctx context.Context) {
+				record(id)
+				Handlers(next).MustOne().Handle(ctx)
+			}, Key(id))
+		}
+	}
+	type branchKey struct{}
+	selectBranch := func(ctx context.Context) Key {
+		return ctx.Value(branchKey{}).(Key)
+	}
+
+	graph := Builders{
+		step("validate"),
+		Builders{step("fetchA"), step("fetchB")}.Parallel(),
+		Builders{step("create"), step("update")}.Switch(selectBranch),
+		step("status"),
+	}.Chain()
+
+	tests := []struct {
+		branch Key
+		want   []string
+	}{
+		{branch: "create", want: []string{"validate", "fetchA", "fetchB", "create", "status"}},
+		{branch: "update", want: []string{"validate", "fetchA", "fetchB", "update", "status"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.branch), func(t *testing.T) {
+			ran = nil
+			ctx := context.WithValue(context.Background(), branchKey{}, tt.branch)
+			graph.Handler("graph").Handle(ctx)
+
+			require.Len(t, ran, len(tt.want))
+			require.Equal(t, tt.want[0], ran[0])
+			// the parallel steps run in either order
+			parallel := append([]string{}, ran[1:3]...)
+			sort.Strings(parallel)
+			require.Equal(t, tt.want[1:3], parallel)
+			require.Equal(t, tt.want[3:], ran[3:])
+		})
+	}
+}
+
+func TestBuildersSequence(t *testing.T) {
+	ran := make([]string, 0)
+	step := func(id string) Builder {
+		return func(next ...Handler) Handler {
+			return NewHandlerFromFunc(func(ctx context.Context) {
+				ran = append(ran, id)
+				// the sequenced steps are built with a NoopHandler
+				require.Equal(t, NextKey, Handlers(next).MustOne().ID())
+				Handlers(next).MustOne().Handle(ctx)
+			}, Key(id))
+		}
+	}
+	next := NewHandlerFromFunc(func(_ context.Context) {
+		ran = append(ran, "next")
+	}, "next")
+
+	seq := Builders{step("first"), step("second")}.Sequence()
+	seq(next).Handle(context.Background())
+	require.Equal(t, []string{"first", "second", "next"}, ran)
+	require.Equal(t, Key("sequence[first,second]"), seq(next).ID())
+
+	// a canceled context stops the sequence, including the next handler
+	ran = ran[:0]
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := func(...Handler) Handler {
+		return NewHandlerFromFunc(func(_ context.Context) {
+			ran = append(ran, "stop")
+			cancel()
+		}, "stop")
+	}
+	Builders{stop, step("after")}.Sequence()(next).Handle(ctx)
+	require.Equal(t, []string{"stop"}, ran)
+}
@@ -1,5 +1,11 @@
 package handler
 
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
 // BuilderComposer is a function that composes sets of handler.Builder into
 // one handler.Builder, see `Chain` and `Parallel`.
 type BuilderComposer func(builder ...Builder) Builder
@@ -16,3 +22,22 @@ func Chain(children ...Builder) Builder {
 }
 
 var _ BuilderComposer = Chain
+
+// Sequence returns a Handler that runs a set of Handlers one after another,
+// passing each the same context. Unlike Chain, the handlers don't need to call
+// a next handler. Queue operations (i.e. Done or Requeue) cancel the context,
+// so Sequence stops before running the next handler once the context is done.
+func Sequence(handlers ...Handler) Handler {
+	ids := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		ids = append(ids, string(h.ID()))
+	}
+	return NewHandler(ContextHandlerFunc(func(ctx context.Context) {
+		for _, h := range handlers {
+			if ctx.Err() != nil {
+				return
+			}
+			h.Handle(ctx)
+		}
+	}), Key(fmt.Sprintf("sequence[%s]", strings.Join(ids, ","))))
+}
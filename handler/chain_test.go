@@ -1,6 +1,12 @@
 package handler
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
 
 func ExampleChain() {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -14,3 +20,64 @@ func ExampleChain() {
 	// Output: the first step
 	// the second step
 }
+
+func ExampleSequence() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	Sequence(
+		firstStageBuilder(NoopHandler),
+		secondStageBuilder(NoopHandler),
+	).Handle(ctx)
+
+	// Output: the first step
+	// the second step
+}
+
+func TestSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		stopAt   int
+		expected []int
+	}{
+		{
+			name:     "runs all handlers in order",
+			stopAt:   -1,
+			expected: []int{0, 1, 2, 3},
+		},
+		{
+			name:     "stops after the context is done",
+			stopAt:   1,
+			expected: []int{0, 1},
+		},
+		{
+			name:     "last handler can stop",
+			stopAt:   3,
+			expected: []int{0, 1, 2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ran := make([]int, 0)
+			handlers := make([]Handler, 0)
+			for i := 0; i < 4; i++ {
+				i := i
+				handlers = append(handlers, NewHandlerFromFunc(func(_ context.Context) {
+					ran = append(ran, i)
+					if i == tt.stopAt {
+						// queue operations cancel the context
+						cancel()
+					}
+				}, Key(fmt.Sprintf("handler-%d", i))))
+			}
+
+			h := Sequence(handlers...)
+			require.Equal(t, Key("sequence[handler-0,handler-1,handler-2,handler-3]"), h.ID())
+			h.Handle(ctx)
+			require.Equal(t, tt.expected, ran)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// Decorator wraps a Handler with cross-cutting behavior, i.e. logging,
+// tracing, or panic recovery. Decorators should keep the ID of the Handler
+// they wrap so that decorated handlers can still be found by Key.
+type Decorator func(Handler) Handler
+
+// Decorate applies the Decorator to each of the handlers.
+func Decorate(d Decorator, handlers ...Handler) Handlers {
+	out := make(Handlers, 0, len(handlers))
+	for _, h := range handlers {
+		out = append(out, d(h))
+	}
+	return out
+}
+
+// RecoverDecorator returns a Decorator that recovers from panics in the
+// wrapped handler and passes them as an error to onPanic instead of crashing
+// the controller. onPanic is typically the RequeueErr method of a
+// queue.OperationsContext, so that the key is retried.
+func RecoverDecorator(onPanic func(ctx context.Context, err error)) Decorator {
+	return func(h Handler) Handler {
+		return NewHandlerFromFunc(func(ctx context.Context) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(ctx, fmt.Errorf("recovered from panic in handler %s: %v\n%s", h.ID(), r, debug.Stack()))
+				}
+			}()
+			h.Handle(ctx)
+		}, h.ID())
+	}
+}
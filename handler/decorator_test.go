@@ -0,0 +1,51 @@
+package handler_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/queue/fake"
+)
+
+func TestDecorate(t *testing.T) {
+	calls := make([]handler.Key, 0)
+	record := func(h handler.Handler) handler.Handler {
+		return handler.NewHandlerFromFunc(func(ctx context.Context) {
+			calls = append(calls, h.ID())
+			h.Handle(ctx)
+		}, h.ID())
+	}
+
+	handlers := handler.Decorate(record,
+		handler.NewHandlerFromFunc(func(_ context.Context) {}, "first"),
+		handler.NewHandlerFromFunc(func(_ context.Context) {}, "second"),
+	)
+	require.Len(t, handlers, 2)
+
+	handlers.MustFind("second").Handle(context.Background())
+	handlers.MustFind("first").Handle(context.Background())
+	require.Equal(t, []handler.Key{"second", "first"}, calls)
+}
+
+func TestRecoverDecorator(t *testing.T) {
+	ctrls := &fake.FakeInterface{}
+	queueOps := queue.NewQueueOperationsCtx()
+	ctx := queueOps.WithValue(context.Background(), ctrls)
+
+	handlers := handler.Decorate(handler.RecoverDecorator(queueOps.RequeueErr),
+		handler.NewHandlerFromFunc(func(_ context.Context) {
+			panic("boom")
+		}, "panics"),
+		handler.NewHandlerFromFunc(func(_ context.Context) {}, "ok"),
+	)
+
+	require.NotPanics(t, func() {
+		handler.Sequence(handlers...).Handle(ctx)
+	})
+	require.Equal(t, 1, ctrls.RequeueErrCallCount())
+	require.ErrorContains(t, ctrls.RequeueErrArgsForCall(0), "recovered from panic in handler panics: boom")
+}
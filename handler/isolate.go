@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"context"
+)
+
+// WithIsolatedContext returns a Handler that runs next in a child of the
+// caller's context, which is canceled as soon as next returns. The handler
+// keeps next's ID.
+//
+// Canceling the child context on return is its only effect: anything next
+// started that is bound to its context (i.e. goroutines waiting on
+// ctx.Done()) is stopped when next returns, without canceling the caller's
+// context. Values are visible exactly as with any child context: next sees
+// every value in the caller's context, values next adds with WithValue are
+// never visible to the caller, and values next stores in a box the caller
+// created (with typedctx.BoxedKey.WithBox) are, since the box is shared.
+func WithIsolatedContext(next Handler) Handler {
+	return NewHandler(ContextHandlerFunc(func(ctx context.Context) {
+		child, cancel := context.WithCancel(ctx)
+		defer cancel()
+		next.Handle(child)
+	}), next.ID())
+}
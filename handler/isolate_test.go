@@ -0,0 +1,44 @@
+package handler_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/typedctx"
+)
+
+func TestWithIsolatedContext(t *testing.T) {
+	var (
+		boxed  = typedctx.Boxed[string]("")
+		plain  = typedctx.NewKey[string]()
+		parent = typedctx.NewKey[string]()
+	)
+
+	var nextCtx context.Context
+	next := handler.NewHandlerFromFunc(func(ctx context.Context) {
+		nextCtx = ctx
+		// next sees the caller's values
+		require.Equal(t, "parent", parent.MustValue(ctx))
+		boxed.WithValue(ctx, "boxed")
+		ctx = plain.WithValue(ctx, "plain")
+		require.Equal(t, "plain", plain.MustValue(ctx))
+	}, "next")
+
+	h := handler.WithIsolatedContext(next)
+	require.Equal(t, handler.Key("next"), h.ID())
+
+	ctx := parent.WithValue(context.Background(), "parent")
+	ctx = boxed.WithBox(ctx)
+	h.Handle(ctx)
+
+	// the boxed value set by next is visible to the caller
+	require.Equal(t, "boxed", boxed.MustValue(ctx))
+	// the plain value set by next is not
+	require.False(t, plain.Present(ctx))
+	// next's context was canceled when it returned, but the caller's wasn't
+	require.ErrorIs(t, nextCtx.Err(), context.Canceled)
+	require.NoError(t, ctx.Err())
+}
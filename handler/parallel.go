@@ -32,3 +32,34 @@ func Parallel(children ...Builder) Builder {
 }
 
 var _ BuilderComposer = Parallel
+
+// FanOut returns a Handler that runs a set of Handlers concurrently, each in
+// its own goroutine with the same context, and returns once all of them have
+// returned. It is the Handler counterpart to Parallel.
+//
+// The handlers share the context, so they share the queue operations for the
+// current key and any values stored in it. Handlers must not write to shared
+// values unless they are safe for concurrent use (i.e. typedctx.Boxed values
+// should be filled in by only one handler). Any handler may requeue or finish
+// the key; this cancels the context for all of them, so long-running handlers
+// should check ctx.Err() and return early. With queue.Operations, the first
+// requeue or done wins, and errors passed to RequeueErr from later handlers
+// are still recorded.
+func FanOut(handlers ...Handler) Handler {
+	ids := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		ids = append(ids, string(h.ID()))
+	}
+	return NewHandler(ContextHandlerFunc(func(ctx context.Context) {
+		var g sync.WaitGroup
+		for _, h := range handlers {
+			h := h
+			g.Add(1)
+			go func() {
+				defer g.Done()
+				h.Handle(ctx)
+			}()
+		}
+		g.Wait()
+	}), Key(fmt.Sprintf("fanout[%s]", strings.Join(ids, ","))))
+}
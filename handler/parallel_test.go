@@ -2,7 +2,12 @@ package handler
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
 	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 func ExampleParallel() {
@@ -28,3 +33,27 @@ func ExampleParallel() {
 	// Output: the second step
 	// the first step
 }
+
+func TestFanOut(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ran atomic.Int32
+	started := make(chan struct{})
+	handlers := make([]Handler, 0)
+	for i := 0; i < 3; i++ {
+		handlers = append(handlers, NewHandlerFromFunc(func(_ context.Context) {
+			// block until all handlers have started, so this only passes if
+			// they run concurrently
+			if ran.Add(1) == 3 {
+				close(started)
+			}
+			<-started
+		}, Key(fmt.Sprintf("handler-%d", i))))
+	}
+
+	h := FanOut(handlers...)
+	require.Equal(t, Key("fanout[handler-0,handler-1,handler-2]"), h.ID())
+	h.Handle(ctx)
+	require.Equal(t, int32(3), ran.Load())
+}
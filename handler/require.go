@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+)
+
+// ContextKey is implemented by context keys that can report whether a value
+// has been stored for them in a context, i.e. the keys in typedctx.
+type ContextKey interface {
+	Present(ctx context.Context) bool
+}
+
+// RequiredKey is a ContextKey with the name it is reported by if it is
+// missing. Context keys carry no name of their own, and keys with the same
+// value type can't be told apart by type.
+type RequiredKey struct {
+	Name string
+	Key  ContextKey
+}
+
+// Required returns a RequiredKey for RequireContext that is reported as name
+// if key is missing.
+func Required(name string, key ContextKey) RequiredKey {
+	return RequiredKey{Name: name, Key: key}
+}
+
+// RequireContext returns a Builder for a handler that checks that every key
+// has a value in the context before calling the next handler. If a key is
+// missing it panics immediately with a message naming the key, instead of
+// failing later in whichever handler first tries to use it.
+func RequireContext(keys ...RequiredKey) Builder {
+	return func(next ...Handler) Handler {
+		return NewHandlerFromFunc(func(ctx context.Context) {
+			for _, k := range keys {
+				if !k.Key.Present(ctx) {
+					panic(fmt.Sprintf("required context key %s not present", k.Name))
+				}
+			}
+			Handlers(next).MustOne().Handle(ctx)
+		}, "requireContext")
+	}
+}
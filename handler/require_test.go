@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testKey struct{ _ byte }
+
+func (k *testKey) Present(ctx context.Context) bool {
+	return ctx.Value(k) != nil
+}
+
+func TestRequireContext(t *testing.T) {
+	// keys of the same type are told apart by the names they are required
+	// with
+	first, second := &testKey{}, &testKey{}
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		expectPanic string
+	}{
+		{
+			name: "all present",
+			ctx:  context.WithValue(context.WithValue(context.Background(), first, 1), second, 2),
+		},
+		{
+			name:        "one missing",
+			ctx:         context.WithValue(context.Background(), first, 1),
+			expectPanic: "required context key second not present",
+		},
+		{
+			name:        "other missing",
+			ctx:         context.WithValue(context.Background(), second, 2),
+			expectPanic: "required context key first not present",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nextCalled := false
+			h := RequireContext(Required("first", first), Required("second", second))(NewHandlerFromFunc(func(_ context.Context) {
+				nextCalled = true
+			}, NextKey))
+
+			if tt.expectPanic != "" {
+				require.PanicsWithValue(t, tt.expectPanic, func() {
+					h.Handle(tt.ctx)
+				})
+				require.False(t, nextCalled)
+				return
+			}
+			h.Handle(tt.ctx)
+			require.True(t, nextCalled)
+		})
+	}
+}
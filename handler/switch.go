@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Switch returns a Handler that calls selector and then runs the handler
+// with the matching Key. It panics if no handler has the selected Key, since
+// that means the handler graph was built incorrectly.
+func Switch(selector func(ctx context.Context) Key, handlers ...Handler) Handler {
+	ids := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		ids = append(ids, string(h.ID()))
+	}
+	set := Handlers(handlers)
+	return NewHandler(ContextHandlerFunc(func(ctx context.Context) {
+		set.MustFind(selector(ctx)).Handle(ctx)
+	}), Key(fmt.Sprintf("switch[%s]", strings.Join(ids, ","))))
+}
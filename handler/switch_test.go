@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwitch(t *testing.T) {
+	type ctxKey struct{}
+	selector := func(ctx context.Context) Key {
+		return ctx.Value(ctxKey{}).(Key)
+	}
+
+	var ran []Key
+	h := Switch(selector,
+		NewHandlerFromFunc(func(_ context.Context) { ran = append(ran, "a") }, "a"),
+		NewHandlerFromFunc(func(_ context.Context) { ran = append(ran, "b") }, "b"),
+	)
+	require.Equal(t, Key("switch[a,b]"), h.ID())
+
+	tests := []struct {
+		name     string
+		selected Key
+		expected []Key
+	}{
+		{
+			name:     "selects a",
+			selected: "a",
+			expected: []Key{"a"},
+		},
+		{
+			name:     "selects b",
+			selected: "b",
+			expected: []Key{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran = nil
+			h.Handle(context.WithValue(context.Background(), ctxKey{}, tt.selected))
+			require.Equal(t, tt.expected, ran)
+		})
+	}
+
+	t.Run("panics if none match", func(t *testing.T) {
+		ran = nil
+		require.PanicsWithValue(t, "handler with id c not found", func() {
+			h.Handle(context.WithValue(context.Background(), ctxKey{}, Key("c")))
+		})
+		require.Empty(t, ran)
+	})
+}
@@ -0,0 +1,69 @@
+package hash
+
+import (
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ServerManagedFields are the fields that the kube API server sets on
+// objects, which SemanticEqualIgnoringServerFields ignores. They can also be
+// passed to WithExcludedFields.
+var ServerManagedFields = []string{
+	"metadata.resourceVersion",
+	"metadata.uid",
+	"metadata.generation",
+	"metadata.creationTimestamp",
+	"metadata.managedFields",
+	"status",
+}
+
+// SemanticEqualIgnoringServerFields returns true if a and b are semantically
+// equal (as in apimachinery's equality.Semantic) once the ServerManagedFields
+// are removed from both. This is useful to compare a desired object with the
+// one read back from the cluster. Typed and unstructured objects can be
+// compared with each other; apiVersion and kind are also ignored, since typed
+// objects usually leave them empty while unstructured ones always set them.
+// A nil pointer of any type is treated as nil. Objects that can't be
+// converted to unstructured are never equal.
+func SemanticEqualIgnoringServerFields(a, b runtime.Object) bool {
+	if isNil(a) || isNil(b) {
+		return isNil(a) && isNil(b)
+	}
+	ua, err := withoutServerFields(a)
+	if err != nil {
+		return false
+	}
+	ub, err := withoutServerFields(b)
+	if err != nil {
+		return false
+	}
+	return equality.Semantic.DeepEqual(ua, ub)
+}
+
+// isNil returns true if obj is nil or a nil pointer.
+func isNil(obj runtime.Object) bool {
+	if obj == nil {
+		return true
+	}
+	v := reflect.ValueOf(obj)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// withoutServerFields returns the unstructured form of obj with the
+// ServerManagedFields, apiVersion, and kind removed.
+func withoutServerFields(obj runtime.Object) (map[string]any, error) {
+	out, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range ServerManagedFields {
+		unstructured.RemoveNestedField(out, strings.Split(f, ".")...)
+	}
+	delete(out, "apiVersion")
+	delete(out, "kind")
+	return out, nil
+}
@@ -0,0 +1,109 @@
+package hash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSemanticEqualIgnoringServerFields(t *testing.T) {
+	desired := func() *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: "test",
+				Labels:    map[string]string{"app": "test"},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeClusterIP,
+				Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+			},
+		}
+	}
+	fromServer := desired()
+	fromServer.ResourceVersion = "12"
+	fromServer.UID = types.UID("a0b1c2")
+	fromServer.Generation = 3
+	fromServer.CreationTimestamp = metav1.Now()
+	fromServer.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "test", Operation: metav1.ManagedFieldsOperationApply}}
+	fromServer.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+
+	unstructuredServer, err := runtime.DefaultUnstructuredConverter.ToUnstructured(fromServer)
+	require.NoError(t, err)
+	// objects read through a dynamic client always have their TypeMeta set,
+	// while typed objects built in code usually don't
+	unstructuredServer["apiVersion"] = "v1"
+	unstructuredServer["kind"] = "Service"
+
+	differentSpec := desired()
+	differentSpec.Spec.Ports[0].Port = 8080
+
+	differentLabels := desired()
+	differentLabels.Labels["app"] = "other"
+
+	tests := []struct {
+		name  string
+		a, b  runtime.Object
+		equal bool
+	}{
+		{
+			name:  "identical",
+			a:     desired(),
+			b:     desired(),
+			equal: true,
+		},
+		{
+			name:  "differ only in server fields",
+			a:     desired(),
+			b:     fromServer,
+			equal: true,
+		},
+		{
+			name:  "typed and unstructured differing only in server fields",
+			a:     desired(),
+			b:     &unstructured.Unstructured{Object: unstructuredServer},
+			equal: true,
+		},
+		{
+			name: "differ in spec",
+			a:    fromServer,
+			b:    differentSpec,
+		},
+		{
+			name: "differ in labels",
+			a:    desired(),
+			b:    differentLabels,
+		},
+		{
+			name: "nil and non-nil",
+			a:    desired(),
+		},
+		{
+			name: "typed nil and non-nil",
+			a:    desired(),
+			b:    (*corev1.Service)(nil),
+		},
+		{
+			name:  "typed nil and nil",
+			a:     (*corev1.Service)(nil),
+			equal: true,
+		},
+		{
+			name:  "typed nils of different types",
+			a:     (*corev1.Service)(nil),
+			b:     (*corev1.Secret)(nil),
+			equal: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.equal, SemanticEqualIgnoringServerFields(tt.a, tt.b))
+			require.Equal(t, tt.equal, SemanticEqualIgnoringServerFields(tt.b, tt.a))
+		})
+	}
+}
@@ -5,11 +5,17 @@ package hash
 import (
 	"crypto/sha512"
 	"crypto/subtle"
+	"encoding/json"
 	"fmt"
+	stdhash "hash"
+	"sort"
+	"strings"
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/davecgh/go-spew/spew"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 )
 
 type (
@@ -44,14 +50,147 @@ func NewSecureObjectHash() ObjectHasher {
 	}
 }
 
-// NewObjectHash returns a new ObjectHasher using Object
-func NewObjectHash() ObjectHasher {
+// ObjectHashOption configures the ObjectHasher returned by NewObjectHash.
+type ObjectHashOption func(*objectHashConfig)
+
+type objectHashConfig struct {
+	excludedFields [][]string
+	mergeKeyMeta   strategicpatch.LookupPatchMeta
+	newHash        func() stdhash.Hash
+}
+
+// WithExcludedFields removes the given fields from objects before they are
+// hashed, so that objects that differ only in those fields hash equal.
+// Fields are dot-separated paths into the JSON form of the object, i.e.
+// `status` or `metadata.managedFields`.
+func WithExcludedFields(fields []string) ObjectHashOption {
+	return func(c *objectHashConfig) {
+		for _, f := range fields {
+			c.excludedFields = append(c.excludedFields, strings.Split(f, "."))
+		}
+	}
+}
+
+// WithSortedMergeKeySlices sorts slices that declare a patch merge key by
+// that key before objects are hashed, so that objects that differ only in the
+// order of, i.e., service ports or container env vars hash equal.
+// dataStruct is the typed kube object that describes the hashed objects (i.e.
+// corev1.Service{}), and is used to look up the declared merge keys. The
+// hashed objects themselves may be typed objects or apply configurations.
+// WithSortedMergeKeySlices panics if dataStruct is not a struct.
+func WithSortedMergeKeySlices(dataStruct any) ObjectHashOption {
+	meta, err := strategicpatch.NewPatchMetaFromStruct(dataStruct)
+	if err != nil {
+		panic(err)
+	}
+	return func(c *objectHashConfig) {
+		c.mergeKeyMeta = meta
+	}
+}
+
+// WithHashAlgorithm sets the digest used to hash objects. The default is
+// xxhash.
+func WithHashAlgorithm(newHash func() stdhash.Hash) ObjectHashOption {
+	return func(c *objectHashConfig) {
+		c.newHash = newHash
+	}
+}
+
+// NewObjectHash returns a new ObjectHasher using Object. Options can be
+// passed to exclude fields from the hash or to change the hash algorithm.
+func NewObjectHash(opts ...ObjectHashOption) ObjectHasher {
+	if len(opts) == 0 {
+		return &hasher{
+			ObjectHashFunc: Object,
+			EqualFunc:      Equal,
+		}
+	}
+
+	config := objectHashConfig{
+		newHash: func() stdhash.Hash { return xxhash.New() },
+	}
+	for _, o := range opts {
+		o(&config)
+	}
 	return &hasher{
-		ObjectHashFunc: Object,
-		EqualFunc:      Equal,
+		ObjectHashFunc: func(obj any) string {
+			return objectWithHasher(config.newHash(), normalize(obj, config))
+		},
+		EqualFunc: Equal,
 	}
 }
 
+// normalize returns the JSON form of obj with the configured fields removed
+// and merge key slices sorted. If there is nothing to normalize or obj can't
+// be converted to a JSON object, obj is returned unchanged.
+func normalize(obj any, config objectHashConfig) any {
+	if len(config.excludedFields) == 0 && config.mergeKeyMeta == nil {
+		return obj
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return obj
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return obj
+	}
+	for _, f := range config.excludedFields {
+		unstructured.RemoveNestedField(out, f...)
+	}
+	if config.mergeKeyMeta != nil {
+		sortMergeKeySlices(out, config.mergeKeyMeta)
+	}
+	return out
+}
+
+// sortMergeKeySlices walks a JSON object alongside its schema and sorts,
+// in place, any slice of objects that declares a patch merge key. Fields that
+// aren't in the schema are left as they are.
+func sortMergeKeySlices(obj map[string]any, meta strategicpatch.LookupPatchMeta) {
+	for k, v := range obj {
+		switch v := v.(type) {
+		case map[string]any:
+			fieldMeta, _, err := meta.LookupPatchMetadataForStruct(k)
+			if err != nil {
+				continue
+			}
+			sortMergeKeySlices(v, fieldMeta)
+		case []any:
+			elemMeta, patchMeta, err := meta.LookupPatchMetadataForSlice(k)
+			if err != nil {
+				continue
+			}
+			for _, elem := range v {
+				if elem, ok := elem.(map[string]any); ok {
+					sortMergeKeySlices(elem, elemMeta)
+				}
+			}
+			mergeKey := patchMeta.GetPatchMergeKey()
+			if mergeKey == "" {
+				continue
+			}
+			sort.SliceStable(v, func(i, j int) bool {
+				return mergeKeyValue(v[i], mergeKey) < mergeKeyValue(v[j], mergeKey)
+			})
+		}
+	}
+}
+
+// mergeKeyValue returns the value of the merge key of a slice element as a
+// string, or the empty string if the element has no merge key.
+func mergeKeyValue(elem any, mergeKey string) string {
+	m, ok := elem.(map[string]any)
+	if !ok {
+		return ""
+	}
+	v, ok := m[mergeKey]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
 // SecureObject canonicalizes the object before hashing with sha512 and then
 // with xxhash
 func SecureObject(obj interface{}) string {
@@ -81,7 +220,11 @@ func SecureEqual(a, b string) bool {
 
 // Object canonicalizes the object before hashing with xxhash
 func Object(obj interface{}) string {
-	hasher := xxhash.New()
+	return objectWithHasher(xxhash.New(), obj)
+}
+
+// objectWithHasher canonicalizes the object before hashing with hasher
+func objectWithHasher(hasher stdhash.Hash, obj interface{}) string {
 	printer := spew.ConfigState{
 		Indent:         " ",
 		SortKeys:       true,
@@ -89,7 +232,7 @@ func Object(obj interface{}) string {
 		SpewKeys:       true,
 	}
 
-	// xxhash's hasher.Write never returns an error, and Fprintf just passes up
+	// hash.Hash's Write never returns an error, and Fprintf just passes up
 	// the underlying Write call's error, so we can safely ignore the error here
 	_, _ = printer.Fprintf(hasher, "%#v", obj)
 	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum(nil)))
@@ -1,9 +1,14 @@
 package hash
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"testing"
 
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
 )
 
 func ExampleObject() {
@@ -27,3 +32,165 @@ func ExampleSecureObject() {
 	fmt.Println(SecureEqual(hash, "n665hb8h667h68hfbhffh669h54dq"))
 	// Output: true
 }
+
+func TestNewObjectHash(t *testing.T) {
+	secret := func(name string, data map[string]string, manager metav1.ManagedFieldsEntry) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:          name,
+				Namespace:     "test",
+				ManagedFields: []metav1.ManagedFieldsEntry{manager},
+			},
+			StringData: data,
+		}
+	}
+	a := secret("a", map[string]string{"some": "data"}, metav1.ManagedFieldsEntry{Manager: "a"})
+	aOtherManager := secret("a", map[string]string{"some": "data"}, metav1.ManagedFieldsEntry{Manager: "b"})
+	aOtherData := secret("a", map[string]string{"other": "data"}, metav1.ManagedFieldsEntry{Manager: "a"})
+
+	tests := []struct {
+		name      string
+		opts      []ObjectHashOption
+		a, b      any
+		wantEqual bool
+	}{
+		{
+			name:      "no options matches Object",
+			a:         a,
+			b:         aOtherManager,
+			wantEqual: false,
+		},
+		{
+			name:      "excluded field differs",
+			opts:      []ObjectHashOption{WithExcludedFields([]string{"metadata.managedFields"})},
+			a:         a,
+			b:         aOtherManager,
+			wantEqual: true,
+		},
+		{
+			name:      "non-excluded field differs",
+			opts:      []ObjectHashOption{WithExcludedFields([]string{"metadata.managedFields"})},
+			a:         a,
+			b:         aOtherData,
+			wantEqual: false,
+		},
+		{
+			name: "excluded field differs with sha256",
+			opts: []ObjectHashOption{
+				WithExcludedFields([]string{"metadata.managedFields"}),
+				WithHashAlgorithm(sha256.New),
+			},
+			a:         a,
+			b:         aOtherManager,
+			wantEqual: true,
+		},
+		{
+			name:      "non-excluded field differs with sha256",
+			opts:      []ObjectHashOption{WithHashAlgorithm(sha256.New)},
+			a:         a,
+			b:         aOtherData,
+			wantEqual: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := NewObjectHash(tt.opts...)
+			require.Equal(t, tt.wantEqual, hasher.Equal(hasher.Hash(tt.a), hasher.Hash(tt.b)))
+		})
+	}
+}
+
+func TestNewObjectHashSortedMergeKeySlices(t *testing.T) {
+	service := func(ports ...int32) *corev1.Service {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "test"},
+		}
+		for _, p := range ports {
+			svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+				Name: fmt.Sprintf("port-%d", p),
+				Port: p,
+			})
+		}
+		return svc
+	}
+	serviceApply := func(ports ...int32) *applycorev1.ServiceApplyConfiguration {
+		spec := applycorev1.ServiceSpec()
+		for _, p := range ports {
+			spec.WithPorts(applycorev1.ServicePort().WithName(fmt.Sprintf("port-%d", p)).WithPort(p))
+		}
+		return applycorev1.Service("svc", "test").WithSpec(spec)
+	}
+
+	tests := []struct {
+		name      string
+		opts      []ObjectHashOption
+		a, b      any
+		wantEqual bool
+	}{
+		{
+			name:      "reordered ports differ without option",
+			a:         service(80, 443),
+			b:         service(443, 80),
+			wantEqual: false,
+		},
+		{
+			name:      "reordered ports are equal",
+			opts:      []ObjectHashOption{WithSortedMergeKeySlices(corev1.Service{})},
+			a:         service(80, 443),
+			b:         service(443, 80),
+			wantEqual: true,
+		},
+		{
+			name:      "different ports are not equal",
+			opts:      []ObjectHashOption{WithSortedMergeKeySlices(corev1.Service{})},
+			a:         service(80, 443),
+			b:         service(80, 8443),
+			wantEqual: false,
+		},
+		{
+			name:      "reordered apply configuration ports are equal",
+			opts:      []ObjectHashOption{WithSortedMergeKeySlices(corev1.Service{})},
+			a:         serviceApply(80, 443, 8080),
+			b:         serviceApply(8080, 443, 80),
+			wantEqual: true,
+		},
+		{
+			name: "reordered ports are equal with excluded fields",
+			opts: []ObjectHashOption{
+				WithSortedMergeKeySlices(corev1.Service{}),
+				WithExcludedFields([]string{"metadata.name"}),
+			},
+			a:         service(80, 443),
+			b:         service(443, 80),
+			wantEqual: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := NewObjectHash(tt.opts...)
+			require.Equal(t, tt.wantEqual, hasher.Equal(hasher.Hash(tt.a), hasher.Hash(tt.b)))
+		})
+	}
+}
+
+func TestWithSortedMergeKeySlicesPanicsOnNonStruct(t *testing.T) {
+	require.Panics(t, func() { WithSortedMergeKeySlices("not a struct") })
+}
+
+func TestNewObjectHashDefault(t *testing.T) {
+	configmap := corev1.ConfigMap{
+		Data: map[string]string{
+			"some": "data",
+		},
+	}
+	require.Equal(t, Object(configmap), NewObjectHash().Hash(configmap))
+}
+
+func TestNewObjectHashAlgorithm(t *testing.T) {
+	configmap := corev1.ConfigMap{
+		Data: map[string]string{
+			"some": "data",
+		},
+	}
+	require.NotEqual(t, Object(configmap), NewObjectHash(WithHashAlgorithm(sha256.New)).Hash(configmap))
+}
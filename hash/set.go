@@ -1,5 +1,7 @@
 package hash
 
+import "sort"
+
 // Set is a set that stores objects based on their Object hash
 type Set[T any] map[string]T
 
@@ -68,6 +70,12 @@ func (s Set[T]) SetDifference(other Set[T]) Set[T] {
 	return result
 }
 
+// SymmetricDifference returns the set of objects that are in exactly one of
+// s and other.
+func (s Set[T]) SymmetricDifference(other Set[T]) Set[T] {
+	return s.SetDifference(other).Union(other.SetDifference(s))
+}
+
 // Union returns the set of objects common to both sets.
 func (s Set[T]) Union(other Set[T]) Set[T] {
 	result := NewSet[T]()
@@ -105,3 +113,53 @@ func (s Set[T]) Pop() (T, bool) {
 	var zero T
 	return zero, false
 }
+
+// Filter returns a new set containing only the objects in s for which pred
+// returns true.
+func (s Set[T]) Filter(pred func(T) bool) Set[T] {
+	result := NewSet[T]()
+	for h, o := range s {
+		if pred(o) {
+			result[h] = o
+		}
+	}
+	return result
+}
+
+// Map returns a new set containing the result of applying f to each object
+// in s. The results are re-hashed, so objects that map to equal values are
+// collapsed into a single element.
+func Map[T, U any](s Set[T], f func(T) U) Set[U] {
+	result := make(Set[U], len(s))
+	for _, o := range s {
+		result.Insert(f(o))
+	}
+	return result
+}
+
+// SortedSlice returns the objects in the set as a slice ordered by less.
+// Objects that less considers equal are ordered by their hash, so the result
+// is the same across calls regardless of map iteration order.
+func (s Set[T]) SortedSlice(less func(a, b T) bool) []T {
+	hashes := make([]string, 0, len(s))
+	for h := range s {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes)
+
+	out := make([]T, 0, len(s))
+	for _, h := range hashes {
+		out = append(out, s[h])
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return less(out[i], out[j])
+	})
+	return out
+}
+
+// SortedStrings returns the strings in the set in lexical order.
+func SortedStrings(s Set[string]) []string {
+	return s.SortedSlice(func(a, b string) bool {
+		return a < b
+	})
+}
@@ -377,6 +377,40 @@ func TestSet_SetDifference(t *testing.T) {
 	}
 }
 
+func TestSet_SymmetricDifference(t *testing.T) {
+	type testCase[T any] struct {
+		name  string
+		s     Set[T]
+		other Set[T]
+		want  Set[T]
+	}
+	tests := []testCase[string]{
+		{
+			name:  "disjoint",
+			s:     NewSet("a", "b"),
+			other: NewSet("c", "d"),
+			want:  NewSet("a", "b", "c", "d"),
+		},
+		{
+			name:  "overlapping",
+			s:     NewSet("a", "b", "c"),
+			other: NewSet("b", "c", "d"),
+			want:  NewSet("a", "d"),
+		},
+		{
+			name:  "equal",
+			s:     NewSet("a", "b"),
+			other: NewSet("a", "b"),
+			want:  NewSet[string](),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.s.SymmetricDifference(tt.other))
+		})
+	}
+}
+
 func TestSet_Union(t *testing.T) {
 	type testCase[T any] struct {
 		name  string
@@ -414,3 +448,137 @@ func TestSet_Union(t *testing.T) {
 		require.Equal(t, tt.want, tt.s.Union(tt.other))
 	}
 }
+
+func TestSet_Filter(t *testing.T) {
+	type testCase[T any] struct {
+		name string
+		s    Set[T]
+		pred func(T) bool
+		want Set[T]
+	}
+	tests := []testCase[string]{
+		{
+			name: "keeps matching",
+			s:    NewSet("a", "bb", "cc"),
+			pred: func(s string) bool { return len(s) == 2 },
+			want: NewSet("bb", "cc"),
+		},
+		{
+			name: "keeps none",
+			s:    NewSet("a", "b"),
+			pred: func(_ string) bool { return false },
+			want: NewSet[string](),
+		},
+		{
+			name: "keeps all",
+			s:    NewSet("a", "b"),
+			pred: func(_ string) bool { return true },
+			want: NewSet("a", "b"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.s.Filter(tt.pred))
+		})
+	}
+}
+
+func TestMap(t *testing.T) {
+	type testCase[T, U any] struct {
+		name string
+		s    Set[T]
+		f    func(T) U
+		want Set[U]
+	}
+	tests := []testCase[MyObject, string]{
+		{
+			name: "maps objects to names",
+			s: NewSet(
+				MyObject{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "test"}},
+				MyObject{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "test"}},
+			),
+			f:    func(o MyObject) string { return o.Name },
+			want: NewSet("a", "b"),
+		},
+		{
+			name: "collapses equal results",
+			s: NewSet(
+				MyObject{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "test"}},
+				MyObject{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "other"}},
+			),
+			f:    func(o MyObject) string { return o.Name },
+			want: NewSet("a"),
+		},
+		{
+			name: "empty",
+			s:    NewSet[MyObject](),
+			f:    func(o MyObject) string { return o.Name },
+			want: NewSet[string](),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Map(tt.s, tt.f))
+		})
+	}
+}
+
+func TestSet_SortedSlice(t *testing.T) {
+	type testCase[T any] struct {
+		name string
+		s    Set[T]
+		less func(a, b T) bool
+		want []T
+	}
+	tests := []testCase[MyObject]{
+		{
+			name: "sorts by comparator",
+			s: NewSet(
+				MyObject{ObjectMeta: metav1.ObjectMeta{Name: "c"}},
+				MyObject{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+				MyObject{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+			),
+			less: func(a, b MyObject) bool { return a.Name < b.Name },
+			want: []MyObject{
+				{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "c"}},
+			},
+		},
+		{
+			name: "empty",
+			s:    NewSet[MyObject](),
+			less: func(a, b MyObject) bool { return a.Name < b.Name },
+			want: []MyObject{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				require.Equal(t, tt.want, tt.s.SortedSlice(tt.less))
+			}
+		})
+	}
+}
+
+func TestSet_SortedSliceTies(t *testing.T) {
+	s := NewSet(
+		MyObject{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "x"}},
+		MyObject{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "y"}},
+		MyObject{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "z"}},
+	)
+	byName := func(a, b MyObject) bool { return a.Name < b.Name }
+
+	// elements that compare equal are still returned in a stable order
+	first := s.SortedSlice(byName)
+	for i := 0; i < 10; i++ {
+		require.Equal(t, first, s.SortedSlice(byName))
+	}
+}
+
+func TestSortedStrings(t *testing.T) {
+	s := NewSet("c", "a", "d", "b")
+	for i := 0; i < 10; i++ {
+		require.Equal(t, []string{"a", "b", "c", "d"}, SortedStrings(s))
+	}
+}
@@ -4,6 +4,7 @@ package healthz
 
 import (
 	"net/http"
+	"strings"
 	"sync"
 
 	"golang.org/x/exp/maps"
@@ -21,15 +22,36 @@ type MutableHealthzHandler struct {
 	// but disallow replacing the handler at the same time (write).
 	mutex  sync.RWMutex
 	checks map[string]healthz.HealthChecker
+	// path is the path the checks are served on, "/healthz" if unset.
+	path string
+	// failureStatus, if set, replaces the 500 status code the healthz
+	// handler uses when a check fails.
+	failureStatus int
 }
 
 func (h *MutableHealthzHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
 
+	if h.failureStatus != 0 {
+		writer = &failureStatusWriter{ResponseWriter: writer, failureStatus: h.failureStatus}
+	}
 	h.handler.ServeHTTP(writer, request)
 }
 
+// failureStatusWriter rewrites 500 responses to failureStatus.
+type failureStatusWriter struct {
+	http.ResponseWriter
+	failureStatus int
+}
+
+func (w *failureStatusWriter) WriteHeader(statusCode int) {
+	if statusCode == http.StatusInternalServerError {
+		statusCode = w.failureStatus
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
 // AddHealthChecker adds health check(s) to the handler.
 //
 // Every time this function is called, the handler have to be re-initiated.
@@ -47,9 +69,7 @@ func (h *MutableHealthzHandler) AddHealthChecker(checks ...healthz.HealthChecker
 	for _, c := range checks {
 		h.checks[c.Name()] = c
 	}
-	newMux := mux.NewPathRecorderMux("healthz")
-	healthz.InstallHandler(newMux, maps.Values(h.checks)...)
-	h.handler = newMux
+	h.handler = h.newMux()
 }
 
 // RemoveHealthChecker removes health check(s) from the handler by name.
@@ -63,9 +83,18 @@ func (h *MutableHealthzHandler) RemoveHealthChecker(names ...string) {
 	for _, n := range names {
 		delete(h.checks, n)
 	}
-	newMux := mux.NewPathRecorderMux("healthz")
-	healthz.InstallHandler(newMux, maps.Values(h.checks)...)
-	h.handler = newMux
+	h.handler = h.newMux()
+}
+
+// newMux returns a handler that serves the current checks.
+func (h *MutableHealthzHandler) newMux() http.Handler {
+	path := h.path
+	if path == "" {
+		path = "/healthz"
+	}
+	newMux := mux.NewPathRecorderMux(strings.TrimPrefix(path, "/"))
+	healthz.InstallPathHandler(newMux, path, maps.Values(h.checks)...)
+	return newMux
 }
 
 func NewMutableHealthzHandler(checks ...healthz.HealthChecker) *MutableHealthzHandler {
@@ -74,3 +103,13 @@ func NewMutableHealthzHandler(checks ...healthz.HealthChecker) *MutableHealthzHa
 
 	return h
 }
+
+// NewMutableReadyzHandler returns a MutableHealthzHandler that serves its
+// checks on "/readyz" instead of "/healthz", and responds with 503 Service
+// Unavailable instead of 500 when a check fails.
+func NewMutableReadyzHandler(checks ...healthz.HealthChecker) *MutableHealthzHandler {
+	h := &MutableHealthzHandler{path: "/readyz", failureStatus: http.StatusServiceUnavailable}
+	h.AddHealthChecker(checks...)
+
+	return h
+}
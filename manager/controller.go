@@ -12,13 +12,18 @@
 // `OwnedResourceController` implements the most common pattern for a
 // controller: reconciling a single resource type via a workqueue. On Start,
 // it begins processing objects from the queue, but it doesn't start any
-// informers itself; that is the responsibility of the caller.
+// informers itself; that is the responsibility of the caller, or of
+// `StartInformers` for the owned resource.
 package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -27,12 +32,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	apisrvhealthz "k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/controller-manager/controller"
 	controllerhealthz "k8s.io/controller-manager/pkg/healthz"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/authzed/controller-idioms/cachekeys"
 	"github.com/authzed/controller-idioms/queue"
@@ -94,21 +101,105 @@ type OwnedResourceController struct {
 	Owned    schema.GroupVersionResource
 	Queue    workqueue.RateLimitingInterface
 	sync     SyncFunc
+
+	// ShutdownDrainTimeout, if set, is how long Start waits after its context
+	// is cancelled for keys that are being processed, and keys that are
+	// already in the queue, to finish before it returns. Keys that are
+	// processing while draining get a context that isn't cancelled until the
+	// drain finishes or times out. Keys that are requeued while draining are
+	// dropped.
+	ShutdownDrainTimeout time.Duration
+
+	// MaxRetries is the number of times a key can be requeued with backoff
+	// (i.e. via Requeue or RequeueErr) before the controller gives up on it.
+	// Requeues with an explicit delay don't count towards the limit. Zero
+	// means there is no limit.
+	MaxRetries int
+
+	// OnGiveUp is called when a key has been requeued MaxRetries times and
+	// would be requeued again. The key is forgotten and not requeued.
+	OnGiveUp func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, numRetries int)
+
+	// SyncTimeout, if set, limits how long the sync func can run for a single
+	// key. When it expires, the key's context is cancelled, and if the sync
+	// func hasn't already marked the key done or requeued it, the key is
+	// requeued with backoff and an error is recorded.
+	SyncTimeout time.Duration
+
+	// TracerProvider is used to create a span around each call to the sync
+	// func, if set.
+	TracerProvider trace.TracerProvider
+
+	// timestamps records when keys are added to Queue
+	timestamps *timestampedQueue
+
+	// recentErrors holds the latest sync errors for DebuggingHandler
+	recentErrors *recentErrors
+
+	// syncedLock guards synced
+	syncedLock sync.RWMutex
+	// synced are checked to determine if the controller is ready
+	synced []cache.InformerSynced
+}
+
+var _ ReadinessCheckable = &OwnedResourceController{}
+
+// OwnedResourceControllerOption configures an OwnedResourceController.
+type OwnedResourceControllerOption func(*ownedResourceControllerConfig)
+
+type ownedResourceControllerConfig struct {
+	rateLimiter workqueue.RateLimiter
+}
+
+// WithRateLimiter sets the rate limiter used to back off requeued keys, in
+// place of workqueue.DefaultControllerRateLimiter. For example, a
+// workqueue.NewItemExponentialFailureRateLimiter with a longer max delay can
+// reduce load from a large number of failing objects.
+func WithRateLimiter(rateLimiter workqueue.RateLimiter) OwnedResourceControllerOption {
+	return func(c *ownedResourceControllerConfig) {
+		c.rateLimiter = rateLimiter
+	}
 }
 
-func NewOwnedResourceController(log logr.Logger, name string, owned schema.GroupVersionResource, key queue.OperationsContext, registry *typed.Registry, broadcaster record.EventBroadcaster, syncFunc SyncFunc) *OwnedResourceController {
+func NewOwnedResourceController(log logr.Logger, name string, owned schema.GroupVersionResource, key queue.OperationsContext, registry *typed.Registry, broadcaster record.EventBroadcaster, syncFunc SyncFunc, opts ...OwnedResourceControllerOption) *OwnedResourceController {
+	config := ownedResourceControllerConfig{
+		rateLimiter: workqueue.DefaultControllerRateLimiter(),
+	}
+	for _, o := range opts {
+		o(&config)
+	}
+
+	queueName := name + "_queue"
+	metricsProvider := workqueueMetricsProvider{controller: name}
+	timestamps := newTimestampedQueue(queueName, metricsProvider)
 	return &OwnedResourceController{
 		log:               log,
 		BasicController:   NewBasicController(name),
 		OperationsContext: key,
 		Registry:          registry,
 		Owned:             owned,
-		Queue:             workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name+"_queue"),
-		Recorder:          broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: name}),
-		sync:              syncFunc,
+		Queue: workqueue.NewRateLimitingQueueWithConfig(config.rateLimiter, workqueue.RateLimitingQueueConfig{
+			Name:            queueName,
+			MetricsProvider: metricsProvider,
+			DelayingQueue: workqueue.NewDelayingQueueWithConfig(workqueue.DelayingQueueConfig{
+				Name:            queueName,
+				MetricsProvider: metricsProvider,
+				Queue:           timestamps,
+			}),
+		}),
+		Recorder:     broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: name}),
+		sync:         syncFunc,
+		timestamps:   timestamps,
+		recentErrors: newRecentErrors(defaultRecentErrorsSize),
 	}
 }
 
+// DebuggingHandler serves the most recent sync errors, oldest first, as a
+// JSON list of SyncError.
+func (c *OwnedResourceController) DebuggingHandler() http.Handler {
+	return c.recentErrors
+}
+
 func (c *OwnedResourceController) Start(ctx context.Context, numThreads int) {
 	defer utilruntime.HandleCrash()
 	defer c.Queue.ShutDown()
@@ -116,11 +207,108 @@ func (c *OwnedResourceController) Start(ctx context.Context, numThreads int) {
 	c.log.V(3).Info("starting controller", "resource", c.Owned)
 	defer c.log.V(3).Info("stopping controller", "resource", c.Owned)
 
+	if c.ShutdownDrainTimeout <= 0 {
+		for i := 0; i < numThreads; i++ {
+			go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+		}
+		<-ctx.Done()
+		return
+	}
+
+	// workers keep running after ctx is cancelled, until the queue is
+	// drained or the drain timeout expires
+	workerCtx, cancelWorkers := context.WithCancel(detachedContext{ctx})
+	defer cancelWorkers()
 	for i := 0; i < numThreads; i++ {
-		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+		go wait.Until(func() { c.startWorker(workerCtx) }, time.Second, workerCtx.Done())
 	}
 
 	<-ctx.Done()
+	c.log.V(3).Info("draining queue", "resource", c.Owned, "timeout", c.ShutdownDrainTimeout)
+	drained := make(chan struct{})
+	go func() {
+		c.Queue.ShutDownWithDrain()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(c.ShutdownDrainTimeout):
+		c.log.V(3).Info("timed out draining queue", "resource", c.Owned)
+	}
+}
+
+// detachedContext is a context.Context that has the values of its parent
+// but is never cancelled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
+
+// StartInformers ensures the informer for the owned GVR exists in the
+// factory registered under factoryKey, adds an event handler that enqueues
+// the owned objects when they change, starts the factory, and waits for the
+// informer's cache to sync. It should be called at most once per controller,
+// since each call adds another event handler.
+func (c *OwnedResourceController) StartInformers(ctx context.Context, factoryKey typed.FactoryKey) error {
+	key := typed.NewRegistryKey(factoryKey, c.Owned)
+	informer, err := c.Registry.InformerForKey(key)
+	if err != nil {
+		return err
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj any) { c.enqueue(obj) },
+		DeleteFunc: func(obj any) { c.enqueue(obj) },
+	}); err != nil {
+		return fmt.Errorf("failed to add handlers for %s: %w", key, err)
+	}
+	c.AddSyncedChecks(informer.HasSynced)
+	_, err = c.Registry.EnsureStarted(key, ctx.Done())
+	return err
+}
+
+// AddSyncedChecks adds funcs that must all return true before the controller
+// reports that it is ready. StartInformers adds the owned informer; callers
+// that start informers themselves should add their HasSynced funcs.
+func (c *OwnedResourceController) AddSyncedChecks(synced ...cache.InformerSynced) {
+	c.syncedLock.Lock()
+	defer c.syncedLock.Unlock()
+	c.synced = append(c.synced, synced...)
+}
+
+// ReadyChecker reports the controller as ready once all of the checks added
+// by AddSyncedChecks pass.
+func (c *OwnedResourceController) ReadyChecker() controllerhealthz.UnnamedHealthChecker {
+	return apisrvhealthz.NamedCheck(c.Name(), func(_ *http.Request) error {
+		c.syncedLock.RLock()
+		defer c.syncedLock.RUnlock()
+		for _, synced := range c.synced {
+			if !synced() {
+				return fmt.Errorf("informers for %s have not synced", c.Name())
+			}
+		}
+		return nil
+	})
+}
+
+// enqueue adds the key for an owned object to the queue.
+func (c *OwnedResourceController) enqueue(obj any) {
+	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	key, err := cachekeys.GVRMetaNamespaceKeyFunc(c.Owned, obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.Queue.Add(key)
 }
 
 func (c *OwnedResourceController) startWorker(ctx context.Context) {
@@ -137,37 +325,105 @@ func (c *OwnedResourceController) processNext(ctx context.Context) bool {
 	key, ok := k.(string)
 	if !ok {
 		utilruntime.HandleError(fmt.Errorf("non-string key found in queue, %T", key))
+		c.timestamps.finish(k)
 		return true
 	}
 
 	gvr, namespace, name, err := cachekeys.SplitGVRMetaNamespaceKey(key)
 	if err != nil {
-		utilruntime.HandleError(fmt.Errorf("error parsing key %q, skipping", key))
+		utilruntime.HandleError(fmt.Errorf("skipping key: %w", err))
+		c.timestamps.finish(k)
 		return true
 	}
 
+	// the per-key context is cancelled by any queue operation, so OnGiveUp
+	// gets the worker's context instead
+	workerCtx := ctx
+	if c.SyncTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, c.SyncTimeout)
+		defer cancelTimeout()
+	}
 	ctx, cancel := context.WithCancel(ctx)
+	ctx, span := c.startSyncSpan(ctx, key, *gvr, namespace, name)
 
+	// requeued is set if the key was requeued, so that marking it done
+	// afterwards doesn't reset its backoff
+	var requeued atomic.Bool
 	done := func() {
-		logr.FromContextOrDiscard(ctx).V(5).Info("done", "key", key)
+		span.setDisposition(dispositionDone)
 		cancel()
-		c.Queue.Forget(key)
+		if !requeued.Load() {
+			c.Queue.Forget(key)
+		}
+		if latency, ok := c.timestamps.finish(key); ok {
+			QueueProcessingLatency.WithLabelValues(c.Name()).Observe(latency.Seconds())
+		}
 	}
 	requeue := func(after time.Duration) {
-		logr.FromContextOrDiscard(ctx).V(5).Info("requeue", "key", key, "after", after)
+		span.setDisposition(dispositionRequeue)
 		cancel()
+		if requeued.Swap(true) {
+			// only requeue once per sync
+			return
+		}
 		if after == 0 {
+			if retries := c.Queue.NumRequeues(key); c.MaxRetries > 0 && retries >= c.MaxRetries {
+				logr.FromContextOrDiscard(ctx).V(2).Info("giving up on key", "key", key, "retries", retries)
+				c.Queue.Forget(key)
+				if c.OnGiveUp != nil {
+					c.OnGiveUp(workerCtx, *gvr, namespace, name, retries)
+				}
+				return
+			}
 			c.Queue.AddRateLimited(key)
 			return
 		}
 		c.Queue.AddAfter(key, after)
 	}
 
-	ctx = c.OperationsContext.WithValue(ctx, queue.NewOperations(done, requeue, cancel))
+	ops := queue.NewOperations(key, done, requeue, cancel).
+		WithLogger(logr.FromContextOrDiscard(ctx)).
+		WithEnqueue(func(key string) {
+			c.Queue.Add(key)
+		})
+	ctx = c.OperationsContext.WithValue(ctx, ops)
 
-	c.sync(ctx, *gvr, namespace, name)
+	syncStart := time.Now()
+	c.syncRecovered(ctx, ops, *gvr, namespace, name)
+	// a queue operation cancels ctx, so it only reports the deadline if the
+	// sync timed out before deciding what to do with the key
+	if c.SyncTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		ops.RequeueErr(fmt.Errorf("sync of %s timed out after %s", key, c.SyncTimeout))
+	}
+	outcome := syncOutcomeDone
+	switch {
+	case ops.Error() != nil:
+		outcome = syncOutcomeError
+	case requeued.Load():
+		outcome = syncOutcomeRequeue
+	}
+	SyncDuration.WithLabelValues(c.Name(), outcome).Observe(time.Since(syncStart).Seconds())
 	done()
 	<-ctx.Done()
+	if err := ops.Error(); err != nil {
+		c.recentErrors.add(key, err)
+	}
+	span.end(ops.Error())
 
 	return true
 }
+
+// syncRecovered calls the sync func, recovering from any panic so that one
+// bad object doesn't stop the worker. A key that panics is requeued with
+// backoff.
+func (c *OwnedResourceController) syncRecovered(ctx context.Context, ops *queue.Operations, gvr schema.GroupVersionResource, namespace, name string) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("panic syncing %s %s/%s: %v", gvr, namespace, name, r)
+			c.log.Error(err, "recovered from panic in sync", "stack", string(debug.Stack()))
+			ops.RequeueErr(err)
+		}
+	}()
+	c.sync(ctx, gvr, namespace, name)
+}
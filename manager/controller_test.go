@@ -4,17 +4,24 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrlmanageropts "k8s.io/controller-manager/options"
 	"k8s.io/klog/v2/klogr"
 
@@ -160,3 +167,285 @@ func healthCheckPassing() func() bool {
 		return resp.StatusCode == http.StatusOK
 	}
 }
+
+func TestControllerRecoversFromSyncPanic(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	var processed sync.Map
+	var panics atomic.Int32
+	controller := NewOwnedResourceController(klogr.New(), "panicking-controller", gvr, queue.NewQueueOperationsCtx(), typed.NewRegistry(), record.NewBroadcaster(), func(_ context.Context, _ schema.GroupVersionResource, _, name string) {
+		if name == "bad" {
+			panics.Add(1)
+			panic("bad object")
+		}
+		processed.Store(name, struct{}{})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// a single worker, so the panic must not stop it for later keys to be
+	// processed
+	go controller.Start(ctx, 1)
+
+	controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/bad"))
+	for i := 0; i < 5; i++ {
+		controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, fmt.Sprintf("test/%d", i)))
+	}
+
+	require.Eventually(t, func() bool {
+		for i := 0; i < 5; i++ {
+			if _, ok := processed.Load(fmt.Sprintf("%d", i)); !ok {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the panicking key is requeued and retried
+	require.Eventually(t, func() bool {
+		return panics.Load() > 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestControllerMaxRetries(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	const maxRetries = 3
+	CtxQueue := queue.NewQueueOperationsCtx()
+	var attempts atomic.Int32
+	controller := NewOwnedResourceController(klogr.New(), "max-retries-controller", gvr, CtxQueue, typed.NewRegistry(), record.NewBroadcaster(), func(ctx context.Context, _ schema.GroupVersionResource, _, _ string) {
+		attempts.Add(1)
+		CtxQueue.RequeueErr(ctx, fmt.Errorf("always fails"))
+	})
+	controller.MaxRetries = maxRetries
+
+	type giveUp struct {
+		gvr             schema.GroupVersionResource
+		namespace, name string
+		numRetries      int
+		ctxErr          error
+	}
+	gaveUp := make(chan giveUp, 2)
+	controller.OnGiveUp = func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, numRetries int) {
+		gaveUp <- giveUp{gvr: gvr, namespace: namespace, name: name, numRetries: numRetries, ctxErr: ctx.Err()}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.Start(ctx, 1)
+
+	key := cachekeys.GVRMetaNamespaceKeyer(gvr, "test/failing")
+	controller.Queue.Add(key)
+
+	select {
+	case g := <-gaveUp:
+		require.Equal(t, giveUp{gvr: gvr, namespace: "test", name: "failing", numRetries: maxRetries}, g)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "OnGiveUp was not called")
+	}
+
+	// the first attempt plus one per retry, and then the key is dropped
+	require.Equal(t, int32(maxRetries+1), attempts.Load())
+	require.Zero(t, controller.Queue.NumRequeues(key))
+	require.Never(t, func() bool {
+		return attempts.Load() > maxRetries+1
+	}, 100*time.Millisecond, 10*time.Millisecond)
+	require.Empty(t, gaveUp)
+}
+
+func TestControllerSyncTimeout(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	CtxQueue := queue.NewQueueOperationsCtx()
+	var attempts atomic.Int32
+	controller := NewOwnedResourceController(klogr.New(), "sync-timeout-controller", gvr, CtxQueue, typed.NewRegistry(), record.NewBroadcaster(), func(ctx context.Context, _ schema.GroupVersionResource, _, _ string) {
+		// the first attempt hangs until it times out
+		if attempts.Add(1) == 1 {
+			<-ctx.Done()
+			return
+		}
+		CtxQueue.Done(ctx)
+	})
+	controller.SyncTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.Start(ctx, 1)
+
+	key := cachekeys.GVRMetaNamespaceKeyer(gvr, "test/hangs")
+	controller.Queue.Add(key)
+
+	require.Eventually(t, func() bool {
+		return attempts.Load() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	errs := controller.recentErrors.list()
+	require.Len(t, errs, 1)
+	require.Equal(t, key, errs[0].Key)
+	require.Contains(t, errs[0].Error, "timed out")
+}
+
+// recordingRateLimiter is a workqueue.RateLimiter that records the items it
+// is asked to rate limit
+type recordingRateLimiter struct {
+	workqueue.RateLimiter
+	when chan any
+}
+
+func (r *recordingRateLimiter) When(item any) time.Duration {
+	r.when <- item
+	return r.RateLimiter.When(item)
+}
+
+func TestControllerWithRateLimiter(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	rateLimiter := &recordingRateLimiter{
+		RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Second),
+		when:        make(chan any, 10),
+	}
+	CtxQueue := queue.NewQueueOperationsCtx()
+	var requeued atomic.Bool
+	controller := NewOwnedResourceController(klogr.New(), "rate-limiter-controller", gvr, CtxQueue, typed.NewRegistry(), record.NewBroadcaster(), func(ctx context.Context, _ schema.GroupVersionResource, _, _ string) {
+		// requeue only the first time, so the limiter is asked once
+		if !requeued.Swap(true) {
+			CtxQueue.Requeue(ctx)
+			return
+		}
+		CtxQueue.Done(ctx)
+	}, WithRateLimiter(rateLimiter))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.Start(ctx, 1)
+
+	key := cachekeys.GVRMetaNamespaceKeyer(gvr, "test/a")
+	controller.Queue.Add(key)
+
+	select {
+	case item := <-rateLimiter.when:
+		require.Equal(t, key, item)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "custom rate limiter was not used")
+	}
+}
+
+func TestControllerStartInformers(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	newObj := func(name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("example.com/v1")
+		obj.SetKind("MyType")
+		obj.SetNamespace("test")
+		obj.SetName(name)
+		return obj
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		gvr: "MyTypeList",
+	}, newObj("existing"))
+
+	registry := typed.NewRegistry()
+	factoryKey := typed.NewFactoryKey("my-controller", "local", "mytypes")
+	registry.MustNewFilteredDynamicSharedInformerFactory(factoryKey, client, 0, metav1.NamespaceAll, nil)
+
+	// the controller isn't started, so keys stay in the queue
+	controller := NewOwnedResourceController(klogr.New(), "informer-controller", gvr, queue.NewQueueOperationsCtx(), registry, record.NewBroadcaster(), func(_ context.Context, _ schema.GroupVersionResource, _, _ string) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, controller.StartInformers(ctx, factoryKey))
+
+	nextKey := func() string {
+		key, _ := controller.Queue.Get()
+		controller.Queue.Done(key)
+		return key.(string)
+	}
+	require.Eventually(t, func() bool {
+		return controller.Queue.Len() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, cachekeys.GVRMetaNamespaceKeyer(gvr, "test/existing"), nextKey())
+
+	_, err := client.Resource(gvr).Namespace("test").Create(ctx, newObj("added"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return controller.Queue.Len() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, cachekeys.GVRMetaNamespaceKeyer(gvr, "test/added"), nextKey())
+
+	require.Error(t, controller.StartInformers(ctx, "unknown"))
+}
+
+func TestControllerShutdownDrain(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	tests := []struct {
+		name           string
+		drainTimeout   time.Duration
+		expectFinished bool
+	}{
+		{
+			name:           "in-flight key finishes before Start returns",
+			drainTimeout:   5 * time.Second,
+			expectFinished: true,
+		},
+		{
+			name:           "drain times out",
+			drainTimeout:   10 * time.Millisecond,
+			expectFinished: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			var finished, cancelled atomic.Bool
+			controller := NewOwnedResourceController(klogr.New(), "draining-controller", gvr, queue.NewQueueOperationsCtx(), typed.NewRegistry(), record.NewBroadcaster(), func(ctx context.Context, _ schema.GroupVersionResource, _, _ string) {
+				close(started)
+				select {
+				case <-time.After(200 * time.Millisecond):
+					finished.Store(true)
+				case <-ctx.Done():
+					cancelled.Store(true)
+				}
+			})
+			controller.ShutdownDrainTimeout = tt.drainTimeout
+
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan struct{})
+			go func() {
+				controller.Start(ctx, 1)
+				close(stopped)
+			}()
+
+			controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/a"))
+			<-started
+			cancel()
+			<-stopped
+
+			require.Equal(t, tt.expectFinished, finished.Load())
+			if tt.expectFinished {
+				require.False(t, cancelled.Load())
+			} else {
+				require.Eventually(t, cancelled.Load, time.Second, 10*time.Millisecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package manager
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultRecentErrorsSize is the number of sync errors an
+// OwnedResourceController keeps for its debugging handler.
+const defaultRecentErrorsSize = 50
+
+// SyncError is a sync error reported by an OwnedResourceController's
+// debugging handler.
+type SyncError struct {
+	Key   string    `json:"key"`
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
+// recentErrors is a fixed-size ring buffer of the most recent sync errors.
+type recentErrors struct {
+	sync.Mutex
+	errs []SyncError
+	// next is the index in errs that the next error is written to
+	next int
+	// full is true once errs has wrapped around
+	full bool
+}
+
+func newRecentErrors(size int) *recentErrors {
+	return &recentErrors{errs: make([]SyncError, size)}
+}
+
+func (r *recentErrors) add(key string, err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.errs[r.next] = SyncError{Key: key, Error: err.Error(), Time: time.Now()}
+	r.next = (r.next + 1) % len(r.errs)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the recorded errors, oldest first.
+func (r *recentErrors) list() []SyncError {
+	r.Lock()
+	defer r.Unlock()
+	if !r.full {
+		return append([]SyncError{}, r.errs[:r.next]...)
+	}
+	return append(append([]SyncError{}, r.errs[r.next:]...), r.errs[:r.next]...)
+}
+
+// ServeHTTP writes the recorded errors as JSON.
+func (r *recentErrors) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(r.list()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/authzed/controller-idioms/cachekeys"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/typed"
+)
+
+func TestRecentErrors(t *testing.T) {
+	keys := func(errs []SyncError) []string {
+		out := make([]string, 0, len(errs))
+		for _, e := range errs {
+			out = append(out, e.Key)
+		}
+		return out
+	}
+
+	r := newRecentErrors(3)
+	require.Empty(t, r.list())
+
+	r.add("a", errors.New("a failed"))
+	r.add("b", errors.New("b failed"))
+	require.Equal(t, []string{"a", "b"}, keys(r.list()))
+
+	r.add("c", errors.New("c failed"))
+	require.Equal(t, []string{"a", "b", "c"}, keys(r.list()))
+
+	// the oldest errors are dropped once the buffer is full
+	r.add("d", errors.New("d failed"))
+	r.add("e", errors.New("e failed"))
+	require.Equal(t, []string{"c", "d", "e"}, keys(r.list()))
+	require.Equal(t, "e failed", r.list()[2].Error)
+}
+
+func TestControllerDebuggingHandler(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	CtxQueue := queue.NewQueueOperationsCtx()
+	controller := NewOwnedResourceController(klogr.New(), "debug-controller", gvr, CtxQueue, typed.NewRegistry(), record.NewBroadcaster(), func(ctx context.Context, _ schema.GroupVersionResource, namespace, name string) {
+		if name == "ok" {
+			return
+		}
+		// requeue after a delay so that each key only fails once
+		CtxQueue.RequeueAfter(ctx, time.Hour)
+		CtxQueue.RequeueErr(ctx, fmt.Errorf("failed to sync %s/%s", namespace, name))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.Start(ctx, 1)
+
+	controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/a"))
+	controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/ok"))
+	controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/b"))
+
+	var errs []SyncError
+	require.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		controller.DebuggingHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errs))
+		return len(errs) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, cachekeys.GVRMetaNamespaceKeyer(gvr, "test/a"), errs[0].Key)
+	require.Equal(t, "failed to sync test/a", errs[0].Error)
+	require.Equal(t, cachekeys.GVRMetaNamespaceKeyer(gvr, "test/b"), errs[1].Key)
+	require.Equal(t, "failed to sync test/b", errs[1].Error)
+	require.False(t, errs[0].Time.IsZero())
+}
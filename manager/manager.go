@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
 	componentconfig "k8s.io/component-base/config"
 	genericcontrollermanager "k8s.io/controller-manager/app"
@@ -18,11 +19,20 @@ import (
 	"github.com/authzed/controller-idioms/healthz"
 )
 
+// ReadinessCheckable is implemented by controllers that aren't ready to
+// serve until some condition is met, i.e. their informer caches have synced.
+// The Manager serves the checks of all of its ReadinessCheckable controllers
+// on "/readyz", while "/healthz" only reports liveness.
+type ReadinessCheckable interface {
+	ReadyChecker() controllerhealthz.UnnamedHealthChecker
+}
+
 // Manager ties a set of controllers to be lifecycled together and exposes common
 // metrics, debug information, and health endpoints for the set.
 type Manager struct {
 	// serving pprof, debug, and health endpoints
 	healthzHandler *healthz.MutableHealthzHandler
+	readyzHandler  *healthz.MutableHealthzHandler
 	srv            *http.Server
 
 	// once prevents concurrent initialization
@@ -37,6 +47,10 @@ type Manager struct {
 	sync.RWMutex
 	cancelFuncs map[Controller]func()
 
+	// managed holds the controllers passed to Go that haven't been cancelled,
+	// whether or not their goroutine has registered a cancel func yet
+	managed map[Controller]struct{}
+
 	// for broadcasting events
 	broadcaster record.EventBroadcaster
 	sink        record.EventSink
@@ -46,17 +60,22 @@ type Manager struct {
 // information for its managed set of controllers.
 func NewManager(debugConfig *componentconfig.DebuggingConfiguration, address string, broadcaster record.EventBroadcaster, sink record.EventSink) *Manager {
 	handler := healthz.NewMutableHealthzHandler()
+	readyzHandler := healthz.NewMutableReadyzHandler()
 	if broadcaster == nil {
 		broadcaster = record.NewBroadcaster()
 	}
+	mux := genericcontrollermanager.NewBaseHandler(debugConfig, handler)
+	mux.Handle("/readyz", readyzHandler)
 	return &Manager{
 		healthzHandler: handler,
+		readyzHandler:  readyzHandler,
 		srv: &http.Server{
-			Handler:           genericcontrollermanager.NewBaseHandler(debugConfig, handler),
+			Handler:           mux,
 			Addr:              address,
 			ReadHeaderTimeout: 20 * time.Second,
 		},
 		cancelFuncs: make(map[Controller]func(), 0),
+		managed:     make(map[Controller]struct{}),
 		broadcaster: broadcaster,
 		sink:        sink,
 	}
@@ -113,6 +132,7 @@ func (m *Manager) Start(ctx context.Context, readyc chan<- struct{}, controllers
 			for ctrl, cancel := range m.cancelFuncs {
 				cancel()
 				delete(m.cancelFuncs, ctrl)
+				delete(m.managed, ctrl)
 			}
 			m.Unlock()
 
@@ -149,7 +169,13 @@ func (m *Manager) Go(controllers ...Controller) error {
 	// start newly added controllers
 	for _, c := range controllers {
 		c := c
+		m.Lock()
+		m.managed[c] = struct{}{}
+		m.Unlock()
 		m.healthzHandler.AddHealthChecker(controllerhealthz.NamedHealthChecker(c.Name(), c.HealthChecker()))
+		if r, ok := c.(ReadinessCheckable); ok {
+			m.readyzHandler.AddHealthChecker(controllerhealthz.NamedHealthChecker(c.Name(), r.ReadyChecker()))
+		}
 		errG.Go(func() error {
 			ctx, cancel := context.WithCancel(ctx)
 			m.Lock()
@@ -167,7 +193,8 @@ func (m *Manager) Go(controllers ...Controller) error {
 	return nil
 }
 
-// Cancel stops the controllers that are passed in
+// Cancel stops the controllers that are passed in and removes their health
+// and readiness checks, so that they no longer affect the manager's health.
 func (m *Manager) Cancel(controllers ...Controller) {
 	names := make([]string, 0, len(controllers))
 	for _, c := range controllers {
@@ -180,7 +207,61 @@ func (m *Manager) Cancel(controllers ...Controller) {
 		names = append(names, c.Name())
 		m.Lock()
 		delete(m.cancelFuncs, c)
+		delete(m.managed, c)
 		m.Unlock()
 	}
 	m.healthzHandler.RemoveHealthChecker(names...)
+	m.readyzHandler.RemoveHealthChecker(names...)
+}
+
+// readyPollInterval is how often WaitForReady checks the controllers.
+const readyPollInterval = 100 * time.Millisecond
+
+// WaitForReady blocks until the manager has been started and every
+// controller it manages is running and ready: its Start func has been called
+// and, if it is ReadinessCheckable, its ready check passes (i.e. its informer
+// caches have synced). Controllers added with Go while waiting must also
+// become ready. WaitForReady may be called before Start.
+//
+// It returns an error if ctx is done first, or if the manager stops.
+func (m *Manager) WaitForReady(ctx context.Context) error {
+	err := wait.PollUntilContextCancel(ctx, readyPollInterval, true, func(ctx context.Context) (bool, error) {
+		m.RLock()
+		if m.errG == nil {
+			m.RUnlock()
+			return false, nil
+		}
+		if err := m.errGCtx.Err(); err != nil {
+			m.RUnlock()
+			return false, fmt.Errorf("manager stopped: %w", err)
+		}
+		controllers := make([]Controller, 0, len(m.managed))
+		for c := range m.managed {
+			if _, running := m.cancelFuncs[c]; !running {
+				m.RUnlock()
+				return false, nil
+			}
+			controllers = append(controllers, c)
+		}
+		m.RUnlock()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/readyz", nil)
+		if err != nil {
+			return false, err
+		}
+		for _, c := range controllers {
+			r, ok := c.(ReadinessCheckable)
+			if !ok {
+				continue
+			}
+			if r.ReadyChecker().Check(req) != nil {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("controllers not ready: %w", err)
+	}
+	return nil
 }
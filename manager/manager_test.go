@@ -2,13 +2,21 @@ package manager
 
 import (
 	"context"
+	"io"
 	"net"
+	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/component-base/config"
 	"k8s.io/klog/v2/klogr"
@@ -91,3 +99,148 @@ func requireCancelFnCount(t *testing.T, m *Manager, count int) {
 		return len(m.cancelFuncs) == count
 	}, 100*time.Second, 10*time.Millisecond)
 }
+
+func TestManagerCancelRemovesHealthCheck(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr := "localhost:" + getFreePort(t)
+	m := NewManager(&config.DebuggingConfiguration{}, addr, nil, nil)
+
+	ready := make(chan struct{})
+	go func() {
+		require.NoError(t, m.Start(ctx, ready, testController(t, "kept")))
+	}()
+	<-ready
+
+	stopped := testController(t, "stopped")
+	require.NoError(t, m.Go(stopped))
+	requireCancelFnCount(t, m, 2)
+
+	checks := func() string {
+		resp, err := http.Get("http://" + addr + "/healthz?verbose")
+		if err != nil {
+			return ""
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	require.Eventually(t, func() bool {
+		return strings.Contains(checks(), "[+]stopped ok")
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Contains(t, checks(), "[+]kept ok")
+
+	m.Cancel(stopped)
+	requireCancelFnCount(t, m, 1)
+	require.NotContains(t, checks(), "stopped")
+	require.Contains(t, checks(), "[+]kept ok")
+}
+
+func TestManagerReadyz(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		gvr: "MyTypeList",
+	})
+	// block the informer's initial list until the test allows it
+	allowList := make(chan struct{})
+	client.PrependReactor("list", "mytypes", func(_ clienttesting.Action) (bool, runtime.Object, error) {
+		<-allowList
+		return false, nil, nil
+	})
+	registry := typed.NewRegistry()
+	factoryKey := typed.NewFactoryKey("readyz-controller", "local", "mytypes")
+	registry.MustNewFilteredDynamicSharedInformerFactory(factoryKey, client, 0, metav1.NamespaceAll, nil)
+	controller := NewOwnedResourceController(klogr.New(), "readyz-controller", gvr, queue.NewQueueOperationsCtx(), registry, record.NewBroadcaster(), func(_ context.Context, _ schema.GroupVersionResource, _, _ string) {})
+
+	addr := "localhost:" + getFreePort(t)
+	m := NewManager(&config.DebuggingConfiguration{}, addr, nil, nil)
+	ready := make(chan struct{})
+	go func() {
+		require.NoError(t, m.Start(ctx, ready, controller))
+	}()
+	<-ready
+
+	statusCode := func(path string) int {
+		resp, err := http.Get("http://" + addr + path)
+		if err != nil {
+			return 0
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	informersStarted := make(chan error)
+	go func() {
+		informersStarted <- controller.StartInformers(ctx, factoryKey)
+	}()
+
+	// alive, but not ready until the informer syncs
+	require.Eventually(t, func() bool {
+		return statusCode("/healthz") == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return statusCode("/readyz") == http.StatusServiceUnavailable
+	}, 5*time.Second, 10*time.Millisecond)
+
+	close(allowList)
+	require.NoError(t, <-informersStarted)
+	require.Equal(t, http.StatusOK, statusCode("/readyz"))
+}
+
+func TestManagerWaitForReady(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var synced atomic.Bool
+	controller := testController(t, "wait-for-ready").(*OwnedResourceController)
+	controller.AddSyncedChecks(synced.Load)
+
+	m := NewManager(&config.DebuggingConfiguration{}, "localhost:"+getFreePort(t), nil, nil)
+
+	// waiting can start before the manager does
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- m.WaitForReady(ctx)
+	}()
+
+	ready := make(chan struct{})
+	go func() {
+		require.NoError(t, m.Start(ctx, ready, controller))
+	}()
+	<-ready
+
+	// errors if the context is done before the controller is ready
+	cancelledCtx, cancelWait := context.WithCancel(ctx)
+	cancelWait()
+	require.ErrorIs(t, m.WaitForReady(cancelledCtx), context.Canceled)
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancelTimeout()
+	require.ErrorIs(t, m.WaitForReady(timeoutCtx), context.DeadlineExceeded)
+
+	select {
+	case err := <-waitErr:
+		require.Fail(t, "WaitForReady returned before the controller was ready", "err: %v", err)
+	default:
+	}
+
+	synced.Store(true)
+	select {
+	case err := <-waitErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "WaitForReady did not return after the controller was ready")
+	}
+	require.NoError(t, m.WaitForReady(ctx))
+}
@@ -0,0 +1,232 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// QueueProcessingLatency reports the time between a key being added to an
+// OwnedResourceController's queue and the controller marking it done.
+var QueueProcessingLatency = metrics.NewHistogramVec(
+	&metrics.HistogramOpts{
+		Subsystem:      "controller",
+		Name:           "queue_processing_latency_seconds",
+		Help:           "Time from a key being added to the queue until its processing is done",
+		Buckets:        metrics.ExponentialBuckets(0.001, 2, 16),
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"controller"},
+)
+
+// Outcomes of a sync, as reported by SyncDuration.
+const (
+	syncOutcomeDone    = "done"
+	syncOutcomeRequeue = "requeue"
+	syncOutcomeError   = "error"
+)
+
+// SyncDuration reports how long each call to an OwnedResourceController's
+// sync func takes, by the outcome of the sync: "error" if an error was
+// recorded, otherwise "requeue" or "done".
+var SyncDuration = metrics.NewHistogramVec(
+	&metrics.HistogramOpts{
+		Subsystem:      "controller",
+		Name:           "sync_duration_seconds",
+		Help:           "Time taken to sync a single key, by outcome",
+		Buckets:        metrics.ExponentialBuckets(0.005, 2, 14),
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"controller", "outcome"},
+)
+
+// The standard workqueue metrics (depth, adds, retries, work duration, etc.)
+// for each OwnedResourceController's queue, labelled by controller name. They
+// are reported through a per-queue workqueue.MetricsProvider rather than the
+// process-global one set with workqueue.SetProvider, so they don't conflict
+// with k8s.io/component-base/metrics/prometheus/workqueue if it is also
+// imported.
+var (
+	workqueueDepth = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Subsystem:      "controller",
+		Name:           "workqueue_depth",
+		Help:           "Current depth of the controller's workqueue",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"controller"})
+	workqueueAdds = metrics.NewCounterVec(&metrics.CounterOpts{
+		Subsystem:      "controller",
+		Name:           "workqueue_adds_total",
+		Help:           "Total number of adds handled by the controller's workqueue",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"controller"})
+	workqueueLatency = metrics.NewHistogramVec(&metrics.HistogramOpts{
+		Subsystem:      "controller",
+		Name:           "workqueue_queue_duration_seconds",
+		Help:           "How long in seconds an item stays in the controller's workqueue before being requested",
+		Buckets:        metrics.ExponentialBuckets(10e-9, 10, 10),
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"controller"})
+	workqueueWorkDuration = metrics.NewHistogramVec(&metrics.HistogramOpts{
+		Subsystem:      "controller",
+		Name:           "workqueue_work_duration_seconds",
+		Help:           "How long in seconds processing an item from the controller's workqueue takes",
+		Buckets:        metrics.ExponentialBuckets(10e-9, 10, 10),
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"controller"})
+	workqueueUnfinishedWork = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Subsystem:      "controller",
+		Name:           "workqueue_unfinished_work_seconds",
+		Help:           "How many seconds of work is in progress and hasn't been observed by work_duration",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"controller"})
+	workqueueLongestRunningProcessor = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Subsystem:      "controller",
+		Name:           "workqueue_longest_running_processor_seconds",
+		Help:           "How many seconds the longest running processor of the controller's workqueue has been running",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"controller"})
+	workqueueRetries = metrics.NewCounterVec(&metrics.CounterOpts{
+		Subsystem:      "controller",
+		Name:           "workqueue_retries_total",
+		Help:           "Total number of retries handled by the controller's workqueue",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"controller"})
+)
+
+func init() {
+	legacyregistry.MustRegister(QueueProcessingLatency)
+	legacyregistry.MustRegister(SyncDuration)
+	legacyregistry.MustRegister(
+		workqueueDepth,
+		workqueueAdds,
+		workqueueLatency,
+		workqueueWorkDuration,
+		workqueueUnfinishedWork,
+		workqueueLongestRunningProcessor,
+		workqueueRetries,
+	)
+}
+
+// workqueueMetricsProvider is a workqueue.MetricsProvider that labels the
+// metrics of a queue with the name of the controller that owns it, instead
+// of the queue's name.
+type workqueueMetricsProvider struct {
+	controller string
+}
+
+var _ workqueue.MetricsProvider = workqueueMetricsProvider{}
+
+func (p workqueueMetricsProvider) NewDepthMetric(string) workqueue.GaugeMetric {
+	return workqueueDepth.WithLabelValues(p.controller)
+}
+
+func (p workqueueMetricsProvider) NewAddsMetric(string) workqueue.CounterMetric {
+	return workqueueAdds.WithLabelValues(p.controller)
+}
+
+func (p workqueueMetricsProvider) NewLatencyMetric(string) workqueue.HistogramMetric {
+	return workqueueLatency.WithLabelValues(p.controller)
+}
+
+func (p workqueueMetricsProvider) NewWorkDurationMetric(string) workqueue.HistogramMetric {
+	return workqueueWorkDuration.WithLabelValues(p.controller)
+}
+
+func (p workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(string) workqueue.SettableGaugeMetric {
+	return workqueueUnfinishedWork.WithLabelValues(p.controller)
+}
+
+func (p workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(string) workqueue.SettableGaugeMetric {
+	return workqueueLongestRunningProcessor.WithLabelValues(p.controller)
+}
+
+func (p workqueueMetricsProvider) NewRetriesMetric(string) workqueue.CounterMetric {
+	return workqueueRetries.WithLabelValues(p.controller)
+}
+
+// timestampedQueue wraps a workqueue.Interface and records when each key
+// was added, so that the time until the key is done can be measured.
+type timestampedQueue struct {
+	workqueue.Interface
+
+	sync.Mutex
+	// added holds the time a key was first added and not yet picked up by
+	// a worker.
+	added map[any]time.Time
+	// processing holds the add time for keys that have been picked up by a
+	// worker. It is separate from added so that a key re-added while it is
+	// being processed gets a fresh timestamp.
+	processing map[any]time.Time
+	// shutDown is set once the queue is shut down, after which keys are no
+	// longer tracked.
+	shutDown bool
+}
+
+func newTimestampedQueue(name string, provider workqueue.MetricsProvider) *timestampedQueue {
+	return &timestampedQueue{
+		Interface: workqueue.NewWithConfig(workqueue.QueueConfig{
+			Name:            name,
+			MetricsProvider: provider,
+		}),
+		added:      make(map[any]time.Time),
+		processing: make(map[any]time.Time),
+	}
+}
+
+func (q *timestampedQueue) Add(item any) {
+	q.Lock()
+	if _, ok := q.added[item]; !ok && !q.shutDown {
+		q.added[item] = time.Now()
+	}
+	q.Unlock()
+	q.Interface.Add(item)
+}
+
+// ShutDown shuts down the queue and stops tracking all keys.
+func (q *timestampedQueue) ShutDown() {
+	q.Interface.ShutDown()
+	q.reset()
+}
+
+// ShutDownWithDrain waits for the keys in the queue to be processed, then
+// shuts it down and stops tracking all keys.
+func (q *timestampedQueue) ShutDownWithDrain() {
+	q.Interface.ShutDownWithDrain()
+	q.reset()
+}
+
+func (q *timestampedQueue) reset() {
+	q.Lock()
+	defer q.Unlock()
+	q.shutDown = true
+	q.added = make(map[any]time.Time)
+	q.processing = make(map[any]time.Time)
+}
+
+func (q *timestampedQueue) Get() (any, bool) {
+	item, shutdown := q.Interface.Get()
+	q.Lock()
+	defer q.Unlock()
+	if added, ok := q.added[item]; ok {
+		q.processing[item] = added
+		delete(q.added, item)
+	}
+	return item, shutdown
+}
+
+// finish stops tracking a key that is being processed and returns the time
+// since it was added. It returns false if the key was not being tracked, i.e.
+// if finish has already been called for this round of processing.
+func (q *timestampedQueue) finish(item any) (time.Duration, bool) {
+	q.Lock()
+	defer q.Unlock()
+	added, ok := q.processing[item]
+	if !ok {
+		return 0, false
+	}
+	delete(q.processing, item)
+	return time.Since(added), true
+}
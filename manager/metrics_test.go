@@ -0,0 +1,200 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/authzed/controller-idioms/cachekeys"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/typed"
+)
+
+func TestQueueProcessingLatency(t *testing.T) {
+	const (
+		name  = "latency-controller"
+		keys  = 5
+		delay = 20 * time.Millisecond
+	)
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	controller := NewOwnedResourceController(klogr.New(), name, gvr, queue.NewQueueOperationsCtx(), typed.NewRegistry(), record.NewBroadcaster(), func(_ context.Context, _ schema.GroupVersionResource, _, _ string) {
+		time.Sleep(delay)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.Start(ctx, 1)
+
+	for i := 0; i < keys; i++ {
+		controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, fmt.Sprintf("test/%d", i)))
+	}
+
+	observer := QueueProcessingLatency.WithLabelValues(name)
+	require.Eventually(t, func() bool {
+		count, err := testutil.GetHistogramMetricCount(observer)
+		require.NoError(t, err)
+		return count == keys
+	}, 5*time.Second, 10*time.Millisecond)
+
+	sum, err := testutil.GetHistogramMetricValue(observer)
+	require.NoError(t, err)
+	// keys are processed by a single worker, so each key waits for the ones
+	// before it in addition to its own processing time
+	require.GreaterOrEqual(t, sum, (keys * delay).Seconds())
+}
+
+func TestQueueTimestampsReleased(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	controller := NewOwnedResourceController(klogr.New(), "timestamps-controller", gvr, queue.NewQueueOperationsCtx(), typed.NewRegistry(), record.NewBroadcaster(), func(_ context.Context, _ schema.GroupVersionResource, _, _ string) {})
+	tracked := func() int {
+		controller.timestamps.Lock()
+		defer controller.timestamps.Unlock()
+		return len(controller.timestamps.added) + len(controller.timestamps.processing)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		controller.Start(ctx, 1)
+		close(stopped)
+	}()
+
+	// keys that can't be parsed are skipped without being synced
+	controller.Queue.Add("not-a-gvr-key")
+	controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/a"))
+	require.Eventually(t, func() bool {
+		return controller.Queue.Len() == 0 && tracked() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-stopped
+	controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/b"))
+	require.Zero(t, tracked())
+}
+
+func TestWorkqueueMetrics(t *testing.T) {
+	const name = "workqueue-metrics-controller"
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	CtxQueue := queue.NewQueueOperationsCtx()
+	var retried atomic.Bool
+	controller := NewOwnedResourceController(klogr.New(), name, gvr, CtxQueue, typed.NewRegistry(), record.NewBroadcaster(), func(ctx context.Context, _ schema.GroupVersionResource, _, name string) {
+		if name == "retry" && retried.CompareAndSwap(false, true) {
+			CtxQueue.Requeue(ctx)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.Start(ctx, 1)
+
+	for _, key := range []string{"a", "b", "retry"} {
+		controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/"+key))
+	}
+
+	// 3 adds plus 1 rate limited re-add for the retry
+	require.Eventually(t, func() bool {
+		return workqueueMetricValue(t, "controller_workqueue_adds_total", name) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return workqueueMetricValue(t, "controller_workqueue_depth", name) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, float64(1), workqueueMetricValue(t, "controller_workqueue_retries_total", name))
+	require.Eventually(t, func() bool {
+		return workqueueMetricValue(t, "controller_workqueue_work_duration_seconds", name) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// workqueueMetricValue returns the value of a counter or gauge, or the sample
+// count of a histogram, for the workqueue of the named controller.
+func workqueueMetricValue(t *testing.T, metric, controllerName string) float64 {
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != metric {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			if !testutil.LabelsMatch(m, map[string]string{"controller": controllerName}) {
+				continue
+			}
+			switch {
+			case m.Counter != nil:
+				return m.GetCounter().GetValue()
+			case m.Gauge != nil:
+				return m.GetGauge().GetValue()
+			case m.Histogram != nil:
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return 0
+}
+
+func TestSyncDuration(t *testing.T) {
+	const name = "sync-duration-controller"
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	CtxQueue := queue.NewQueueOperationsCtx()
+	var retried, failed atomic.Bool
+	controller := NewOwnedResourceController(klogr.New(), name, gvr, CtxQueue, typed.NewRegistry(), record.NewBroadcaster(), func(ctx context.Context, _ schema.GroupVersionResource, _, name string) {
+		switch {
+		case name == "retry" && retried.CompareAndSwap(false, true):
+			CtxQueue.Requeue(ctx)
+		case name == "fail" && failed.CompareAndSwap(false, true):
+			CtxQueue.RequeueErr(ctx, fmt.Errorf("failed"))
+		default:
+			CtxQueue.Done(ctx)
+		}
+	})
+
+	doneBefore := syncCount(t, name, "done")
+	requeueBefore := syncCount(t, name, "requeue")
+	errorBefore := syncCount(t, name, "error")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.Start(ctx, 1)
+
+	for _, key := range []string{"a", "retry", "fail"} {
+		controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/"+key))
+	}
+
+	// each key is done once, after the retry and failure are requeued
+	require.Eventually(t, func() bool {
+		return syncCount(t, name, "done")-doneBefore == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, uint64(1), syncCount(t, name, "requeue")-requeueBefore)
+	require.Equal(t, uint64(1), syncCount(t, name, "error")-errorBefore)
+}
+
+// syncCount returns the number of syncs observed by SyncDuration for the
+// controller and outcome.
+func syncCount(t *testing.T, controllerName, outcome string) uint64 {
+	count, err := testutil.GetHistogramMetricCount(SyncDuration.WithLabelValues(controllerName, outcome))
+	require.NoError(t, err)
+	return count
+}
@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/typed"
+	"github.com/authzed/controller-idioms/typedctx"
+)
+
+// RetryingSyncFunc returns a SyncFunc that calls fn and maps its result to a
+// queue operation: the key is marked done when fn returns nil, and requeued
+// via RequeueAPIErr otherwise, so that transient kube api errors are retried
+// with the delay suggested by the apiserver.
+func RetryingSyncFunc(ctrls queue.OperationsContext, fn func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) error) SyncFunc {
+	return func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) {
+		if err := fn(ctx, gvr, namespace, name); err != nil {
+			ctrls.RequeueAPIErr(ctx, err)
+			return
+		}
+		ctrls.Done(ctx)
+	}
+}
+
+var (
+	// CtxOwnedObject holds the namespace and name of the object being synced
+	// by a HandlerSync.
+	CtxOwnedObject = typedctx.NewKey[types.NamespacedName]()
+	// CtxRegistry holds the Registry passed to HandlerSync.
+	CtxRegistry = typedctx.NewKey[*typed.Registry]()
+)
+
+// HandlerSync returns a SyncFunc that builds a handler for each key with
+// build and then calls it. In addition to the queue operations that every
+// SyncFunc gets, the context passed to the handler holds the owned object's
+// namespace and name in CtxOwnedObject, and registry in CtxRegistry.
+func HandlerSync(registry *typed.Registry, build func(gvr schema.GroupVersionResource, namespace, name string) handler.Handler) SyncFunc {
+	return func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) {
+		ctx = CtxOwnedObject.WithValue(ctx, types.NamespacedName{Namespace: namespace, Name: name})
+		ctx = CtxRegistry.WithValue(ctx, registry)
+		build(gvr, namespace, name).Handle(ctx)
+	}
+}
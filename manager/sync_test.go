@@ -0,0 +1,124 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/authzed/controller-idioms/cachekeys"
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/queue/fake"
+	"github.com/authzed/controller-idioms/typed"
+)
+
+func TestRetryingSyncFunc(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	tests := []struct {
+		name string
+		err  error
+
+		expectDone          bool
+		expectRequeueAPIErr error
+	}{
+		{
+			name:       "done on success",
+			expectDone: true,
+		},
+		{
+			name:                "requeues on throttling error",
+			err:                 apierrors.NewTooManyRequests("slow down", 1),
+			expectRequeueAPIErr: apierrors.NewTooManyRequests("slow down", 1),
+		},
+		{
+			name:                "requeues on other error",
+			err:                 errors.New("failed"),
+			expectRequeueAPIErr: errors.New("failed"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrls := &fake.FakeInterface{}
+			queueOps := queue.NewQueueOperationsCtx()
+			ctx := queueOps.WithValue(context.Background(), ctrls)
+
+			var gotNamespace, gotName string
+			RetryingSyncFunc(queueOps, func(_ context.Context, gotGVR schema.GroupVersionResource, namespace, name string) error {
+				require.Equal(t, gvr, gotGVR)
+				gotNamespace, gotName = namespace, name
+				return tt.err
+			})(ctx, gvr, "test", "a")
+
+			require.Equal(t, "test", gotNamespace)
+			require.Equal(t, "a", gotName)
+			require.Equal(t, tt.expectDone, ctrls.DoneCallCount() == 1)
+			if tt.expectRequeueAPIErr != nil {
+				require.Equal(t, 1, ctrls.RequeueAPIErrCallCount())
+				require.Equal(t, tt.expectRequeueAPIErr, ctrls.RequeueAPIErrArgsForCall(0))
+			} else {
+				require.Zero(t, ctrls.RequeueAPIErrCallCount())
+			}
+		})
+	}
+}
+
+func TestHandlerSync(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	CtxQueue := queue.NewQueueOperationsCtx()
+	registry := typed.NewRegistry()
+
+	type handled struct {
+		gvr        schema.GroupVersionResource
+		nn         types.NamespacedName
+		registry   *typed.Registry
+		hasQueueOp bool
+	}
+	handledCh := make(chan handled, 1)
+	controller := NewOwnedResourceController(klogr.New(), "handler-sync-controller", gvr, CtxQueue, registry, record.NewBroadcaster(),
+		HandlerSync(registry, func(gvr schema.GroupVersionResource, _, _ string) handler.Handler {
+			return handler.NewHandlerFromFunc(func(ctx context.Context) {
+				_, hasQueueOp := CtxQueue.Value(ctx)
+				handledCh <- handled{
+					gvr:        gvr,
+					nn:         CtxOwnedObject.MustValue(ctx),
+					registry:   CtxRegistry.MustValue(ctx),
+					hasQueueOp: hasQueueOp,
+				}
+				CtxQueue.Done(ctx)
+			}, "test")
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.Start(ctx, 1)
+	controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/a"))
+
+	select {
+	case h := <-handledCh:
+		require.Equal(t, handled{
+			gvr:        gvr,
+			nn:         types.NamespacedName{Namespace: "test", Name: "a"},
+			registry:   registry,
+			hasQueueOp: true,
+		}, h)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "handler was not called")
+	}
+}
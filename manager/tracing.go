@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const tracerName = "github.com/authzed/controller-idioms/manager"
+
+// Dispositions recorded on sync spans
+const (
+	dispositionDone    = "done"
+	dispositionRequeue = "requeue"
+)
+
+// syncSpan is the span for a single call to an OwnedResourceController's
+// sync func. A nil syncSpan is valid and does nothing, so that tracing has no
+// cost when no TracerProvider is configured.
+type syncSpan struct {
+	span            trace.Span
+	dispositionOnce sync.Once
+}
+
+// startSyncSpan starts a span for processing key if the controller has a
+// TracerProvider, and returns a context containing the span.
+func (c *OwnedResourceController) startSyncSpan(ctx context.Context, key string, gvr schema.GroupVersionResource, namespace, name string) (context.Context, *syncSpan) {
+	if c.TracerProvider == nil {
+		return ctx, nil
+	}
+	ctx, span := c.TracerProvider.Tracer(tracerName).Start(ctx, "sync",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("controller", c.Name()),
+			attribute.String("queue.key", key),
+			attribute.String("gvr", gvr.String()),
+			attribute.String("namespace", namespace),
+			attribute.String("name", name),
+		),
+	)
+	return ctx, &syncSpan{span: span}
+}
+
+// setDisposition records what happened to the key. Only the first call has
+// any effect, since later calls (i.e. the controller marking the key done
+// after the sync func has requeued it) don't change the outcome.
+func (s *syncSpan) setDisposition(disposition string) {
+	if s == nil {
+		return
+	}
+	s.dispositionOnce.Do(func() {
+		s.span.SetAttributes(attribute.String("disposition", disposition))
+	})
+}
+
+// end records err, if any, and ends the span.
+func (s *syncSpan) end(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2/klogr"
+
+	"github.com/authzed/controller-idioms/cachekeys"
+	"github.com/authzed/controller-idioms/queue"
+	"github.com/authzed/controller-idioms/typed"
+)
+
+func TestControllerSyncSpans(t *testing.T) {
+	gvr := schema.GroupVersionResource{
+		Group:    "example.com",
+		Version:  "v1",
+		Resource: "mytypes",
+	}
+	syncErr := errors.New("sync failed")
+	CtxQueue := queue.NewQueueOperationsCtx()
+	controller := NewOwnedResourceController(klogr.New(), "traced-controller", gvr, CtxQueue, typed.NewRegistry(), record.NewBroadcaster(), func(ctx context.Context, _ schema.GroupVersionResource, _, name string) {
+		switch name {
+		case "requeue":
+			CtxQueue.RequeueAfter(ctx, time.Hour)
+		case "error":
+			CtxQueue.RequeueErr(ctx, syncErr)
+		}
+	})
+	exporter := tracetest.NewInMemoryExporter()
+	controller.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go controller.Start(ctx, 1)
+
+	names := []string{"a", "b", "requeue", "error"}
+	for _, name := range names {
+		controller.Queue.Add(cachekeys.GVRMetaNamespaceKeyer(gvr, "test/"+name))
+	}
+	require.Eventually(t, func() bool {
+		return len(exporter.GetSpans()) >= len(names)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	type result struct {
+		disposition string
+		status      codes.Code
+	}
+	results := make(map[string]result)
+	for _, span := range exporter.GetSpans() {
+		attrs := attribute.NewSet(span.Attributes...)
+		name, ok := attrs.Value("name")
+		require.True(t, ok)
+		key, ok := attrs.Value("queue.key")
+		require.True(t, ok)
+		require.Equal(t, cachekeys.GVRMetaNamespaceKeyer(gvr, "test/"+name.AsString()), key.AsString())
+		namespace, _ := attrs.Value("namespace")
+		require.Equal(t, "test", namespace.AsString())
+		controllerName, _ := attrs.Value("controller")
+		require.Equal(t, "traced-controller", controllerName.AsString())
+		disposition, _ := attrs.Value("disposition")
+		results[name.AsString()] = result{disposition: disposition.AsString(), status: span.Status.Code}
+	}
+	require.Equal(t, map[string]result{
+		"a":       {disposition: dispositionDone, status: codes.Unset},
+		"b":       {disposition: dispositionDone, status: codes.Unset},
+		"requeue": {disposition: dispositionRequeue, status: codes.Unset},
+		"error":   {disposition: dispositionRequeue, status: codes.Error},
+	}, results)
+}
@@ -2,14 +2,19 @@
 //
 // For any resource that implements the standard `metav1.Conditions` array in
 // its status, `ConditionStatusCollector` will report metrics on how many
-// objects have been in certain conditions, and for how long.
+// objects have been in certain conditions, for how long, and how often those
+// conditions change.
 package metrics
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/component-base/metrics"
 
@@ -22,18 +27,80 @@ type ConditionStatusCollector[K pause.HasStatusConditions] struct {
 	metrics.BaseStableCollector
 
 	listerBuilders []func() ([]K, error)
+	config         conditionStatusCollectorConfig[K]
 
-	ObjectCount           *metrics.Desc
-	ObjectConditionCount  *metrics.Desc
-	ObjectTimeInCondition *metrics.Desc
-	CollectorTime         *metrics.Desc
-	CollectorErrors       *metrics.Desc
+	// transitionsLock guards the state kept between collections to count
+	// condition transitions.
+	transitionsLock sync.Mutex
+	// lastTransitions holds the last transition time seen for each
+	// condition of each object, per lister builder.
+	lastTransitions []map[objectCondition]time.Time
+	transitions     map[conditionStatus]uint64
+
+	ObjectCount          *metrics.Desc
+	ObjectConditionCount *metrics.Desc
+	// ObjectConditionStatusCount breaks ObjectConditionCount down by the
+	// status of the condition (True, False, or Unknown).
+	ObjectConditionStatusCount *metrics.Desc
+	ObjectTimeInCondition      *metrics.Desc
+	// ObjectPausedCount counts objects with a True Paused condition, by the
+	// reason for the pause.
+	ObjectPausedCount *metrics.Desc
+	// ObjectConditionTransitions counts the condition transitions observed
+	// between collections, by condition type and the status transitioned to.
+	ObjectConditionTransitions *metrics.Desc
+	CollectorTime              *metrics.Desc
+	CollectorErrors            *metrics.Desc
+}
+
+// ConditionStatusCollectorOption configures a ConditionStatusCollector.
+type ConditionStatusCollectorOption[K pause.HasStatusConditions] func(*conditionStatusCollectorConfig[K])
+
+type conditionStatusCollectorConfig[K pause.HasStatusConditions] struct {
+	omitObjectLabel bool
+	labelNames      []string
+	labelFunc       func(K) map[string]string
+}
+
+// WithObjectLabels adds the labels in labelNames to the per-object
+// time-in-condition metric. The values are taken from the map returned by
+// labelFunc for each object; names missing from the map get an empty value.
+// A nil labelFunc adds no labels.
+func WithObjectLabels[K pause.HasStatusConditions](labelNames []string, labelFunc func(K) map[string]string) ConditionStatusCollectorOption[K] {
+	return func(c *conditionStatusCollectorConfig[K]) {
+		if labelFunc == nil {
+			return
+		}
+		c.labelNames = labelNames
+		c.labelFunc = labelFunc
+	}
+}
+
+// WithoutObjectLabel drops the high-cardinality `object` label from the
+// time-in-condition metric. Objects that share the same condition and custom
+// labels are reported as one series, with the longest time in the condition.
+func WithoutObjectLabel[K pause.HasStatusConditions]() ConditionStatusCollectorOption[K] {
+	return func(c *conditionStatusCollectorConfig[K]) {
+		c.omitObjectLabel = true
+	}
 }
 
 // NewConditionStatusCollector creates a new ConditionStatusCollector, with
 // flags for specifying how to generate the names of the metrics.
-func NewConditionStatusCollector[K pause.HasStatusConditions](namespace string, subsystem string, resourceName string) *ConditionStatusCollector[K] {
+func NewConditionStatusCollector[K pause.HasStatusConditions](namespace string, subsystem string, resourceName string, opts ...ConditionStatusCollectorOption[K]) *ConditionStatusCollector[K] {
+	var config conditionStatusCollectorConfig[K]
+	for _, o := range opts {
+		o(&config)
+	}
+	timeInConditionLabels := []string{"condition"}
+	if !config.omitObjectLabel {
+		timeInConditionLabels = append(timeInConditionLabels, "object")
+	}
+	timeInConditionLabels = append(timeInConditionLabels, config.labelNames...)
+
 	return &ConditionStatusCollector[K]{
+		config:      config,
+		transitions: make(map[conditionStatus]uint64),
 		ObjectCount: metrics.NewDesc(
 			prometheus.BuildFQName(namespace, subsystem, "count"),
 			fmt.Sprintf("Gauge showing the number of %s managed by this operator", resourceName),
@@ -46,12 +113,32 @@ func NewConditionStatusCollector[K pause.HasStatusConditions](namespace string,
 				"condition",
 			}, nil, metrics.ALPHA, "",
 		),
+		ObjectConditionStatusCount: metrics.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "condition_status"),
+			fmt.Sprintf("Gauge showing the number of %s with each type and status of condition", resourceName),
+			[]string{
+				"condition",
+				"status",
+			}, nil, metrics.ALPHA, "",
+		),
 		ObjectTimeInCondition: metrics.NewDesc(
 			prometheus.BuildFQName(namespace, subsystem, "condition_time_seconds"),
 			fmt.Sprintf("Gauge showing the amount of time %s have spent in the current condition", resourceName),
+			timeInConditionLabels, nil, metrics.ALPHA, "",
+		),
+		ObjectPausedCount: metrics.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "paused"),
+			fmt.Sprintf("Gauge showing the number of %s that are paused, by the reason for the pause", resourceName),
+			[]string{
+				"reason",
+			}, nil, metrics.ALPHA, "",
+		),
+		ObjectConditionTransitions: metrics.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "condition_transitions_total"),
+			fmt.Sprintf("Counter of the condition transitions of %s observed by the status collector", resourceName),
 			[]string{
 				"condition",
-				"object",
+				"status",
 			}, nil, metrics.ALPHA, "",
 		),
 		CollectorTime: metrics.NewDesc(
@@ -67,14 +154,43 @@ func NewConditionStatusCollector[K pause.HasStatusConditions](namespace string,
 	}
 }
 
+// RegisterConditionStatusCollector creates a ConditionStatusCollector with
+// the given listers and registers it with registry. If an identical collector
+// is already registered (i.e. by a previous call with the same names), the
+// listers are added to that collector instead, and it is returned.
+func RegisterConditionStatusCollector[K pause.HasStatusConditions](registry metrics.KubeRegistry, namespace, subsystem, resourceName string, listers ...func() ([]K, error)) (*ConditionStatusCollector[K], error) {
+	collector := NewConditionStatusCollector[K](namespace, subsystem, resourceName)
+	if err := registry.CustomRegister(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &alreadyRegistered) {
+			return nil, err
+		}
+		existing, ok := alreadyRegistered.ExistingCollector.(*ConditionStatusCollector[K])
+		if !ok {
+			return nil, fmt.Errorf("a different collector is registered for %s: %w", resourceName, err)
+		}
+		collector = existing
+	}
+	for _, lb := range listers {
+		collector.AddListerBuilder(lb)
+	}
+	return collector, nil
+}
+
 func (c *ConditionStatusCollector[K]) AddListerBuilder(lb func() ([]K, error)) {
+	c.transitionsLock.Lock()
+	defer c.transitionsLock.Unlock()
 	c.listerBuilders = append(c.listerBuilders, lb)
+	c.lastTransitions = append(c.lastTransitions, nil)
 }
 
 func (c *ConditionStatusCollector[K]) DescribeWithStability(ch chan<- *metrics.Desc) {
 	ch <- c.ObjectCount
 	ch <- c.ObjectConditionCount
+	ch <- c.ObjectConditionStatusCount
 	ch <- c.ObjectTimeInCondition
+	ch <- c.ObjectPausedCount
+	ch <- c.ObjectConditionTransitions
 	ch <- c.CollectorTime
 	ch <- c.CollectorErrors
 }
@@ -91,35 +207,116 @@ func (c *ConditionStatusCollector[K]) CollectWithStability(ch chan<- metrics.Met
 
 	collectTime := time.Now()
 
-	for _, lb := range c.listerBuilders {
+	c.transitionsLock.Lock()
+	defer c.transitionsLock.Unlock()
+
+	// the objects from all listers are reported together, so that each
+	// series is only reported once
+	listed := false
+	objectCount := 0
+	objectsWithCondition := map[string]uint16{}
+	objectsWithConditionStatus := map[conditionStatus]uint16{}
+	// report both pause reasons even if there are no paused objects
+	pausedObjects := map[string]uint16{
+		pause.ConditionReasonPausedByLabel:      0,
+		pause.ConditionReasonPausedByController: 0,
+	}
+	timeInCondition := map[string]*labelledValue{}
+
+	for i, lb := range c.listerBuilders {
 		objs, err := lb()
 		if err != nil {
 			totalErrors++
 			continue
 		}
+		listed = true
+		objectCount += len(objs)
 
-		ch <- metrics.NewLazyConstMetric(c.ObjectCount, metrics.GaugeValue, float64(len(objs)))
-
-		objectsWithCondition := map[string]uint16{}
+		previousTransitions := c.lastTransitions[i]
+		currentTransitions := make(map[objectCondition]time.Time)
 		for _, o := range objs {
-			objectName := types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}.String()
+			if paused := o.FindStatusCondition(pause.ConditionTypePaused); paused != nil && paused.Status == metav1.ConditionTrue {
+				pausedObjects[paused.Reason]++
+			}
+			objectLabels := c.objectLabelValues(o)
 			for _, condition := range *o.GetStatusConditions() {
 				objectsWithCondition[condition.Type]++
+				cs := conditionStatus{conditionType: condition.Type, status: string(condition.Status)}
+				objectsWithConditionStatus[cs]++
 
-				timeInCondition := collectTime.Sub(condition.LastTransitionTime.Time)
+				oc := objectCondition{namespace: o.GetNamespace(), name: o.GetName(), conditionType: condition.Type}
+				currentTransitions[oc] = condition.LastTransitionTime.Time
+				// objects seen for the first time aren't counted, since there
+				// is nothing to compare their conditions against
+				if last, ok := previousTransitions[oc]; ok && !last.Equal(condition.LastTransitionTime.Time) {
+					c.transitions[cs]++
+				}
 
-				ch <- metrics.NewLazyConstMetric(
-					c.ObjectTimeInCondition,
-					metrics.GaugeValue,
-					timeInCondition.Seconds(),
-					condition.Type,
-					objectName,
-				)
+				seconds := collectTime.Sub(condition.LastTransitionTime.Time).Seconds()
+				labelValues := append([]string{condition.Type}, objectLabels...)
+				key := strings.Join(labelValues, "\xff")
+				if existing, ok := timeInCondition[key]; !ok || seconds > existing.value {
+					timeInCondition[key] = &labelledValue{labelValues: labelValues, value: seconds}
+				}
 			}
 		}
 
+		c.lastTransitions[i] = currentTransitions
+	}
+
+	if listed {
+		ch <- metrics.NewLazyConstMetric(c.ObjectCount, metrics.GaugeValue, float64(objectCount))
+		for _, v := range timeInCondition {
+			ch <- metrics.NewLazyConstMetric(c.ObjectTimeInCondition, metrics.GaugeValue, v.value, v.labelValues...)
+		}
 		for conditionType, count := range objectsWithCondition {
 			ch <- metrics.NewLazyConstMetric(c.ObjectConditionCount, metrics.GaugeValue, float64(count), conditionType)
 		}
+		for cs, count := range objectsWithConditionStatus {
+			ch <- metrics.NewLazyConstMetric(c.ObjectConditionStatusCount, metrics.GaugeValue, float64(count), cs.conditionType, cs.status)
+		}
+		for reason, count := range pausedObjects {
+			ch <- metrics.NewLazyConstMetric(c.ObjectPausedCount, metrics.GaugeValue, float64(count), reason)
+		}
 	}
+
+	for cs, count := range c.transitions {
+		ch <- metrics.NewLazyConstMetric(c.ObjectConditionTransitions, metrics.CounterValue, float64(count), cs.conditionType, cs.status)
+	}
+}
+
+// objectLabelValues returns the values of the time-in-condition labels that
+// come from the object itself, i.e. everything but the condition.
+func (c *ConditionStatusCollector[K]) objectLabelValues(o K) []string {
+	values := make([]string, 0, len(c.config.labelNames)+1)
+	if !c.config.omitObjectLabel {
+		values = append(values, types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}.String())
+	}
+	if len(c.config.labelNames) == 0 {
+		return values
+	}
+	custom := c.config.labelFunc(o)
+	for _, name := range c.config.labelNames {
+		values = append(values, custom[name])
+	}
+	return values
+}
+
+// labelledValue is a metric value along with its label values
+type labelledValue struct {
+	labelValues []string
+	value       float64
+}
+
+// objectCondition identifies a condition of a specific object
+type objectCondition struct {
+	namespace     string
+	name          string
+	conditionType string
+}
+
+// conditionStatus is a condition type and status pair
+type conditionStatus struct {
+	conditionType string
+	status        string
 }
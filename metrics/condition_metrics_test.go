@@ -1,10 +1,18 @@
 package metrics
 
 import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics"
 	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
 
 	"github.com/authzed/controller-idioms/conditions"
+	"github.com/authzed/controller-idioms/pause"
 )
 
 type MyObject struct {
@@ -28,3 +36,227 @@ func ExampleNewConditionStatusCollector() {
 	legacyregistry.CustomMustRegister(databaseMetrics)
 	// Output:
 }
+
+func newMyObject(name string, conds ...metav1.Condition) *MyObject {
+	return &MyObject{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+		StatusWithConditions: conditions.StatusWithConditions[*MyObjectStatus]{
+			Status: &MyObjectStatus{
+				StatusConditions: conditions.StatusConditions{Conditions: conds},
+			},
+		},
+	}
+}
+
+func TestConditionStatusCount(t *testing.T) {
+	collector := NewConditionStatusCollector[*MyObject]("test", "objects", "myobjecttype")
+	collector.AddListerBuilder(func() ([]*MyObject, error) {
+		return []*MyObject{
+			newMyObject("a",
+				metav1.Condition{Type: "Degraded", Status: metav1.ConditionTrue},
+				metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse},
+			),
+			newMyObject("b",
+				metav1.Condition{Type: "Degraded", Status: metav1.ConditionTrue},
+				metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue},
+			),
+			newMyObject("c",
+				metav1.Condition{Type: "Degraded", Status: metav1.ConditionFalse},
+				metav1.Condition{Type: "Ready", Status: metav1.ConditionUnknown},
+			),
+		}, nil
+	})
+
+	require.NoError(t, testutil.CustomCollectAndCompare(collector, strings.NewReader(`
+# HELP test_objects_condition_status [ALPHA] Gauge showing the number of myobjecttype with each type and status of condition
+# TYPE test_objects_condition_status gauge
+test_objects_condition_status{condition="Degraded",status="False"} 1
+test_objects_condition_status{condition="Degraded",status="True"} 2
+test_objects_condition_status{condition="Ready",status="False"} 1
+test_objects_condition_status{condition="Ready",status="True"} 1
+test_objects_condition_status{condition="Ready",status="Unknown"} 1
+`), "test_objects_condition_status"))
+}
+
+func TestPausedCount(t *testing.T) {
+	collector := NewConditionStatusCollector[*MyObject]("test", "objects", "myobjecttype")
+	collector.AddListerBuilder(func() ([]*MyObject, error) {
+		return []*MyObject{
+			newMyObject("label-paused-a", pause.NewPausedCondition("example.com/paused")),
+			newMyObject("label-paused-b", pause.NewPausedCondition("example.com/paused")),
+			newMyObject("self-paused", pause.NewSelfPausedCondition("example.com/paused")),
+			newMyObject("not-paused",
+				metav1.Condition{Type: pause.ConditionTypePaused, Status: metav1.ConditionFalse, Reason: pause.ConditionReasonPausedByLabel},
+			),
+			newMyObject("no-conditions"),
+		}, nil
+	})
+
+	require.NoError(t, testutil.CustomCollectAndCompare(collector, strings.NewReader(`
+# HELP test_objects_paused [ALPHA] Gauge showing the number of myobjecttype that are paused, by the reason for the pause
+# TYPE test_objects_paused gauge
+test_objects_paused{reason="PausedByController"} 1
+test_objects_paused{reason="PausedByLabel"} 2
+`), "test_objects_paused"))
+}
+
+func TestPausedCountNoPausedObjects(t *testing.T) {
+	collector := NewConditionStatusCollector[*MyObject]("test", "objects", "myobjecttype")
+	collector.AddListerBuilder(func() ([]*MyObject, error) {
+		return []*MyObject{newMyObject("a")}, nil
+	})
+
+	require.NoError(t, testutil.CustomCollectAndCompare(collector, strings.NewReader(`
+# HELP test_objects_paused [ALPHA] Gauge showing the number of myobjecttype that are paused, by the reason for the pause
+# TYPE test_objects_paused gauge
+test_objects_paused{reason="PausedByController"} 0
+test_objects_paused{reason="PausedByLabel"} 0
+`), "test_objects_paused"))
+}
+
+// timeInConditionLabels gathers the time-in-condition metric from the
+// collector and returns the labels of each series.
+func timeInConditionLabels(t *testing.T, collector *ConditionStatusCollector[*MyObject]) []map[string]string {
+	registry := metrics.NewKubeRegistry()
+	registry.CustomMustRegister(collector)
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	var series []map[string]string
+	for _, family := range families {
+		if family.GetName() != "test_objects_condition_time_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			series = append(series, labels)
+		}
+	}
+	return series
+}
+
+func tenantLabels(o *MyObject) map[string]string {
+	return map[string]string{"tenant": o.GetLabels()["tenant"]}
+}
+
+func TestTimeInConditionCustomLabels(t *testing.T) {
+	collector := NewConditionStatusCollector[*MyObject]("test", "objects", "myobjecttype",
+		WithObjectLabels([]string{"tenant"}, tenantLabels),
+	)
+	collector.AddListerBuilder(func() ([]*MyObject, error) {
+		a := newMyObject("a", metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue})
+		a.SetLabels(map[string]string{"tenant": "blue"})
+		return []*MyObject{a}, nil
+	})
+
+	require.Equal(t, []map[string]string{
+		{"condition": "Ready", "object": "test/a", "tenant": "blue"},
+	}, timeInConditionLabels(t, collector))
+}
+
+func TestTimeInConditionNilLabelFunc(t *testing.T) {
+	collector := NewConditionStatusCollector[*MyObject]("test", "objects", "myobjecttype",
+		WithObjectLabels[*MyObject]([]string{"tenant"}, nil),
+	)
+	collector.AddListerBuilder(func() ([]*MyObject, error) {
+		return []*MyObject{newMyObject("a", metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue})}, nil
+	})
+
+	require.Equal(t, []map[string]string{
+		{"condition": "Ready", "object": "test/a"},
+	}, timeInConditionLabels(t, collector))
+}
+
+func TestTimeInConditionWithoutObjectLabel(t *testing.T) {
+	now := time.Now()
+	collector := NewConditionStatusCollector[*MyObject]("test", "objects", "myobjecttype",
+		WithObjectLabels([]string{"tenant"}, tenantLabels),
+		WithoutObjectLabel[*MyObject](),
+	)
+	collector.AddListerBuilder(func() ([]*MyObject, error) {
+		a := newMyObject("a", metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-time.Hour))})
+		a.SetLabels(map[string]string{"tenant": "blue"})
+		b := newMyObject("b", metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-time.Minute))})
+		b.SetLabels(map[string]string{"tenant": "blue"})
+		c := newMyObject("c", metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now)})
+		return []*MyObject{a, b, c}, nil
+	})
+
+	require.ElementsMatch(t, []map[string]string{
+		{"condition": "Ready", "tenant": "blue"},
+		{"condition": "Ready", "tenant": ""},
+	}, timeInConditionLabels(t, collector))
+}
+
+func TestConditionTransitions(t *testing.T) {
+	now := time.Now()
+	objs := []*MyObject{
+		newMyObject("a",
+			metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse, LastTransitionTime: metav1.NewTime(now.Add(-time.Hour))},
+			metav1.Condition{Type: "Degraded", Status: metav1.ConditionFalse, LastTransitionTime: metav1.NewTime(now.Add(-time.Hour))},
+		),
+		newMyObject("b",
+			metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-time.Hour))},
+		),
+	}
+	collector := NewConditionStatusCollector[*MyObject]("test", "objects", "myobjecttype")
+	collector.AddListerBuilder(func() ([]*MyObject, error) {
+		return objs, nil
+	})
+	registry := metrics.NewKubeRegistry()
+	registry.CustomMustRegister(collector)
+
+	// the first collection has nothing to compare against
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(""), "test_objects_condition_transitions_total"))
+
+	// a becomes ready, b is unchanged
+	objs[0] = newMyObject("a",
+		metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now)},
+		metav1.Condition{Type: "Degraded", Status: metav1.ConditionFalse, LastTransitionTime: metav1.NewTime(now.Add(-time.Hour))},
+	)
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP test_objects_condition_transitions_total [ALPHA] Counter of the condition transitions of myobjecttype observed by the status collector
+# TYPE test_objects_condition_transitions_total counter
+test_objects_condition_transitions_total{condition="Ready",status="True"} 1
+`), "test_objects_condition_transitions_total"))
+
+	// a becomes unready again, and the counter keeps its previous values
+	objs[0] = newMyObject("a",
+		metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse, LastTransitionTime: metav1.NewTime(now.Add(time.Minute))},
+		metav1.Condition{Type: "Degraded", Status: metav1.ConditionFalse, LastTransitionTime: metav1.NewTime(now.Add(-time.Hour))},
+	)
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP test_objects_condition_transitions_total [ALPHA] Counter of the condition transitions of myobjecttype observed by the status collector
+# TYPE test_objects_condition_transitions_total counter
+test_objects_condition_transitions_total{condition="Ready",status="False"} 1
+test_objects_condition_transitions_total{condition="Ready",status="True"} 1
+`), "test_objects_condition_transitions_total"))
+}
+
+func TestRegisterConditionStatusCollector(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	collector, err := RegisterConditionStatusCollector[*MyObject](registry, "test", "objects", "myobjecttype", func() ([]*MyObject, error) {
+		return []*MyObject{
+			newMyObject("a", metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue}),
+			newMyObject("b", metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse}),
+		}, nil
+	})
+	require.NoError(t, err)
+
+	// registering again adds the listers to the existing collector
+	again, err := RegisterConditionStatusCollector[*MyObject](registry, "test", "objects", "myobjecttype", func() ([]*MyObject, error) {
+		return []*MyObject{newMyObject("c", metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue})}, nil
+	})
+	require.NoError(t, err)
+	require.Same(t, collector, again)
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP test_objects_condition_status [ALPHA] Gauge showing the number of myobjecttype with each type and status of condition
+# TYPE test_objects_condition_status gauge
+test_objects_condition_status{condition="Ready",status="False"} 1
+test_objects_condition_status{condition="Ready",status="True"} 2
+`), "test_objects_condition_status"))
+}
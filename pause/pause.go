@@ -29,6 +29,16 @@ import (
 
 const ConditionTypePaused = "Paused"
 
+// Reasons set on the Paused condition
+const (
+	// ConditionReasonPausedByLabel is set when a user paused the object by
+	// adding the paused label.
+	ConditionReasonPausedByLabel = "PausedByLabel"
+	// ConditionReasonPausedByController is set when the controller paused
+	// the object via SelfPause.
+	ConditionReasonPausedByController = "PausedByController"
+)
+
 // HasStatusConditions is an interface that any object implementing standard
 // condition accessors will satisfy.
 type HasStatusConditions interface {
@@ -161,7 +171,7 @@ func NewPausedCondition(pausedLabelKey string) metav1.Condition {
 	return metav1.Condition{
 		Type:               ConditionTypePaused,
 		Status:             metav1.ConditionTrue,
-		Reason:             "PausedByLabel",
+		Reason:             ConditionReasonPausedByLabel,
 		LastTransitionTime: metav1.NewTime(time.Now()),
 		Message:            fmt.Sprintf("Controller pause requested via label: %s", pausedLabelKey),
 	}
@@ -171,7 +181,7 @@ func NewSelfPausedCondition(pausedLabelKey string) metav1.Condition {
 	return metav1.Condition{
 		Type:               ConditionTypePaused,
 		Status:             metav1.ConditionTrue,
-		Reason:             "PausedByController",
+		Reason:             ConditionReasonPausedByController,
 		LastTransitionTime: metav1.NewTime(time.Now()),
 		Message:            fmt.Sprintf("Reconiciliation has been paused by the controller; see other conditions for more information. When ready, unpause by removing the %s label", pausedLabelKey),
 	}
@@ -9,8 +9,12 @@
 // - Requeue (requeue the current key)
 // - RequeueAfter (wait for some period of time before requeuing the current key)
 // - ReqeueueErr (record an error and requeue)
+// - RequeueAfterErr (record an error and wait for some period of time before requeuing)
 // - RequeueAPIError (requeue after waiting according to the priority and fairness response from the apiserver)
 //
+// Handlers can also Enqueue a key other than the current one, e.g. to have
+// an owner or dependent of the current object reconciled.
+//
 // If calling these controls from a handler, it's important to `return`
 // immediately so that the handler does not continue processing a key that
 // the queue thinks has stopped.
@@ -18,6 +22,8 @@ package queue
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -38,39 +44,66 @@ func NewQueueOperationsCtx() OperationsContext {
 }
 
 func (h OperationsContext) Done(ctx context.Context) {
-	h.MustValue(ctx).Done()
+	h.operations(ctx).Done()
 }
 
 func (h OperationsContext) RequeueAfter(ctx context.Context, duration time.Duration) {
-	h.MustValue(ctx).RequeueAfter(duration)
+	h.operations(ctx).RequeueAfter(duration)
 }
 
 func (h OperationsContext) Requeue(ctx context.Context) {
-	h.MustValue(ctx).Requeue()
+	h.operations(ctx).Requeue()
 }
 
 func (h OperationsContext) RequeueErr(ctx context.Context, err error) {
-	logr.FromContextOrDiscard(ctx).V(4).WithCallDepth(3).Error(err, "requeueing after error")
-	h.MustValue(ctx).RequeueErr(err)
+	h.operations(ctx).RequeueErr(err)
+}
+
+func (h OperationsContext) RequeueAfterErr(ctx context.Context, err error, duration time.Duration) {
+	h.operations(ctx).RequeueAfterErr(err, duration)
 }
 
 func (h OperationsContext) RequeueAPIErr(ctx context.Context, err error) {
-	logr.FromContextOrDiscard(ctx).V(4).WithCallDepth(3).Error(err, "requeueing after api error")
-	h.MustValue(ctx).RequeueAPIErr(err)
+	h.operations(ctx).RequeueAPIErr(err)
+}
+
+func (h OperationsContext) Enqueue(ctx context.Context, key string) {
+	h.operations(ctx).Enqueue(key)
 }
 
 func (h OperationsContext) Error(ctx context.Context) error {
 	return h.MustValue(ctx).Error()
 }
 
-func NewOperations(done func(), requeueAfter func(time.Duration), cancel context.CancelFunc) *Operations {
+// operations returns the Interface stored in ctx. Operations without a
+// logger set by WithLogger log with the logger from ctx.
+func (h OperationsContext) operations(ctx context.Context) Interface {
+	ops := h.MustValue(ctx)
+	if o, ok := ops.(*Operations); ok {
+		o.loggerOnce.Do(func() {
+			o.logger = logr.FromContextOrDiscard(ctx)
+		})
+	}
+	return ops
+}
+
+// NewOperations returns Operations for the queue key `key`. The key is only
+// used for logging; done and requeueAfter act on the queue.
+func NewOperations(key string, done func(), requeueAfter func(time.Duration), cancel context.CancelFunc) *Operations {
 	return &Operations{
+		key:          key,
 		done:         done,
 		requeueAfter: requeueAfter,
 		cancel:       cancel,
 	}
 }
 
+// Dispositions of the current key, as logged by Operations.
+const (
+	DispositionDone    = "done"
+	DispositionRequeue = "requeue"
+)
+
 // Interface is the standard queue control interface
 //
 //counterfeiter:generate -o ./fake/zz_generated.go . Interface
@@ -79,17 +112,37 @@ type Interface interface {
 	RequeueAfter(duration time.Duration)
 	Requeue()
 	RequeueErr(err error)
+	RequeueAfterErr(err error, duration time.Duration)
 	RequeueAPIErr(err error)
+	Enqueue(key string)
 	Error() error
 }
 
 // Operations deals with the current queue key and provides controls for
 // requeueing or stopping reconciliation.
+// Operations is safe to use from handlers running concurrently (i.e. via
+// handler.FanOut). Only the first disposition of the current key (Done or one
+// of the Requeue operations) takes effect; later ones only record their
+// errors. If more than one error is recorded, Error returns them joined
+// together.
+//
+// Each disposition of the current key is logged at V(4) with the fields
+// key, disposition, and, where relevant, delay and error. The logger is the
+// one set with WithLogger or, failing that, the one in the context of the
+// first OperationsContext call.
 type Operations struct {
+	key          string
+	loggerOnce   sync.Once
+	logger       logr.Logger
 	done         func()
 	requeueAfter func(duration time.Duration)
 	cancel       context.CancelFunc
-	err          error
+	enqueue      func(key string)
+
+	errLock sync.Mutex
+	err     error
+	// disposed is set by the first disposition of the current key
+	disposed bool
 }
 
 // Done marks the current key as finished. Note that processing should stop
@@ -97,44 +150,138 @@ type Operations struct {
 // queue to potentially process the same key again.
 func (c *Operations) Done() {
 	defer c.cancel()
-	c.done()
+	c.markDone(nil)
 }
 
 // RequeueAfter requeues the current key after duration.
 func (c *Operations) RequeueAfter(duration time.Duration) {
 	defer c.cancel()
-	c.requeueAfter(duration)
+	c.requeue(duration, nil)
 }
 
 // Requeue requeues the current key immediately.
 func (c *Operations) Requeue() {
 	defer c.cancel()
-	c.requeueAfter(0)
+	c.requeue(0, nil)
 }
 
 // RequeueErr sets err on the object and requeues the current key.
 func (c *Operations) RequeueErr(err error) {
 	defer c.cancel()
-	c.err = err
-	c.requeueAfter(0)
+	c.recordErr(err)
+	c.requeue(0, err)
+}
+
+// RequeueAfterErr sets err on the object and requeues the current key after
+// duration.
+func (c *Operations) RequeueAfterErr(err error, duration time.Duration) {
+	defer c.cancel()
+	c.recordErr(err)
+	c.requeue(duration, err)
 }
 
 // RequeueAPIErr checks to see if `err` is a kube api error with retry data.
 // If so, it requeues after the wait period, otherwise, it requeues immediately.
+// Errors that aren't transient (see ShouldRetry) mark the current key done.
 func (c *Operations) RequeueAPIErr(err error) {
 	defer c.cancel()
-	c.err = err
-	retry, after := ShouldRetry(err)
-	if retry && after > 0 {
-		c.RequeueAfter(after)
+	c.recordErr(err)
+	if retry, after := ShouldRetry(err); retry {
+		c.requeue(after, err)
+		return
 	}
-	if retry {
-		c.Requeue()
+	c.markDone(err)
+}
+
+// WithLogger sets the logger used to log dispositions of the current key. It
+// has no effect once the Operations have been used.
+func (c *Operations) WithLogger(logger logr.Logger) *Operations {
+	c.loggerOnce.Do(func() {
+		c.logger = logger
+	})
+	return c
+}
+
+// log returns the logger for the current key, discarding logs if none was
+// set.
+func (c *Operations) log() logr.Logger {
+	c.loggerOnce.Do(func() {
+		c.logger = logr.Discard()
+	})
+	return c.logger
+}
+
+// WithEnqueue sets the func used by Enqueue to add other keys to the queue.
+func (c *Operations) WithEnqueue(enqueue func(key string)) *Operations {
+	c.enqueue = enqueue
+	return c
+}
+
+// Enqueue adds key to the queue. Unlike the other operations, it doesn't
+// affect the current key, which still needs to be marked done or requeued.
+// It panics if no enqueue func was set with WithEnqueue.
+func (c *Operations) Enqueue(key string) {
+	if c.enqueue == nil {
+		panic("queue: Enqueue called on Operations without an enqueue func")
 	}
-	c.Done()
+	c.log().V(4).Info("enqueueing key", "key", c.key, "enqueued", key)
+	c.enqueue(key)
 }
 
-// Error returns the last recorded error, if any
+// Error returns the recorded error, if any
 func (c *Operations) Error() error {
+	c.errLock.Lock()
+	defer c.errLock.Unlock()
 	return c.err
 }
+
+// markDone logs and marks the current key done, unless it already has a
+// disposition
+func (c *Operations) markDone(err error) {
+	if !c.dispose() {
+		return
+	}
+	keysAndValues := []any{"key", c.key, "disposition", DispositionDone}
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err.Error())
+	}
+	c.log().V(4).Info("queue disposition", keysAndValues...)
+	c.done()
+}
+
+// requeue logs and requeues the current key after delay, unless it already
+// has a disposition
+func (c *Operations) requeue(delay time.Duration, err error) {
+	if !c.dispose() {
+		return
+	}
+	keysAndValues := []any{"key", c.key, "disposition", DispositionRequeue, "delay", delay}
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err.Error())
+	}
+	c.log().V(4).Info("queue disposition", keysAndValues...)
+	c.requeueAfter(delay)
+}
+
+// dispose returns true the first time it is called, and false after that.
+func (c *Operations) dispose() bool {
+	c.errLock.Lock()
+	defer c.errLock.Unlock()
+	if c.disposed {
+		c.log().V(4).Info("ignoring disposition of key that already has one", "key", c.key)
+		return false
+	}
+	c.disposed = true
+	return true
+}
+
+// recordErr stores err, joining it with any previously recorded error.
+func (c *Operations) recordErr(err error) {
+	c.errLock.Lock()
+	defer c.errLock.Unlock()
+	if c.err == nil {
+		c.err = err
+		return
+	}
+	c.err = errors.Join(c.err, err)
+}
@@ -2,9 +2,17 @@ package queue
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync/atomic"
+	"testing"
 	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/authzed/controller-idioms/handler"
@@ -21,7 +29,7 @@ func ExampleNewOperations() {
 	key, _ := queue.Get()
 
 	// operations are per-key
-	operations := NewOperations(func() {
+	operations := NewOperations("current_key", func() {
 		queue.Done(key)
 	}, func(duration time.Duration) {
 		queue.AddAfter(key, duration)
@@ -34,7 +42,16 @@ func ExampleNewOperations() {
 	}, "example").Handle(ctx)
 	fmt.Println(queue.Len())
 
+	// when the key is processed again, it gets new operations
+	queue.Add("current_key")
+	key, _ = queue.Get()
+	operations = NewOperations("current_key", func() {
+		queue.Done(key)
+	}, func(duration time.Duration) {
+		queue.AddAfter(key, duration)
+	}, cancel)
 	operations.Requeue()
+	queue.Done(key)
 	fmt.Println(queue.Len())
 
 	// Output: 0
@@ -53,7 +70,7 @@ func ExampleNewQueueOperationsCtx() {
 	key, _ := queue.Get()
 
 	// operations are per-key
-	CtxQueue := NewQueueOperationsCtx().WithValue(ctx, NewOperations(func() {
+	CtxQueue := NewQueueOperationsCtx().WithValue(ctx, NewOperations("current_key", func() {
 		queue.Done(key)
 	}, func(duration time.Duration) {
 		queue.AddAfter(key, duration)
@@ -68,3 +85,256 @@ func ExampleNewQueueOperationsCtx() {
 	fmt.Println(queue.Len())
 	// Output: 0
 }
+
+func TestOperationsFanOut(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requeues atomic.Int32
+	operations := NewOperations("current_key", func() {}, func(_ time.Duration) {
+		requeues.Add(1)
+	}, cancel)
+	ctrls := NewQueueOperationsCtx()
+	ctx = ctrls.WithValue(ctx, operations)
+
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	var ran atomic.Int32
+	handler.FanOut(
+		handler.NewHandlerFromFunc(func(ctx context.Context) {
+			ran.Add(1)
+			ctrls.RequeueErr(ctx, errA)
+		}, "a"),
+		handler.NewHandlerFromFunc(func(ctx context.Context) {
+			ran.Add(1)
+			ctrls.RequeueErr(ctx, errB)
+		}, "b"),
+		handler.NewHandlerFromFunc(func(ctx context.Context) {
+			ran.Add(1)
+		}, "c"),
+	).Handle(ctx)
+
+	// the first requeue wins; the other only records its error
+	require.Equal(t, int32(3), ran.Load())
+	require.Equal(t, int32(1), requeues.Load())
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+	require.ErrorIs(t, operations.Error(), errA)
+	require.ErrorIs(t, operations.Error(), errB)
+}
+
+func TestOperationsFirstDispositionWins(t *testing.T) {
+	_, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var done int
+	var requeueAfters []time.Duration
+	operations := NewOperations("current_key", func() {
+		done++
+	}, func(duration time.Duration) {
+		requeueAfters = append(requeueAfters, duration)
+	}, cancel)
+
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	operations.RequeueAfterErr(errA, time.Second)
+	operations.RequeueErr(errB)
+	operations.Requeue()
+	operations.Done()
+
+	require.Equal(t, []time.Duration{time.Second}, requeueAfters)
+	require.Zero(t, done)
+	require.ErrorIs(t, operations.Error(), errA)
+	require.ErrorIs(t, operations.Error(), errB)
+}
+
+func TestOperationsSingleError(t *testing.T) {
+	_, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	operations := NewOperations("current_key", func() {}, func(_ time.Duration) {}, cancel)
+	err := errors.New("failed")
+	operations.RequeueErr(err)
+	require.Equal(t, err, operations.Error())
+}
+
+func TestRequeueAfterErr(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requeuedAfter []time.Duration
+	operations := NewOperations("current_key", func() {
+		require.Fail(t, "done should not be called")
+	}, func(duration time.Duration) {
+		requeuedAfter = append(requeuedAfter, duration)
+	}, cancel)
+	ctrls := NewQueueOperationsCtx()
+	ctx = ctrls.WithValue(ctx, operations)
+
+	err := errors.New("failed")
+	ctrls.RequeueAfterErr(ctx, err, 5*time.Second)
+
+	require.Equal(t, []time.Duration{5 * time.Second}, requeuedAfter)
+	require.Equal(t, err, ctrls.Error(ctx))
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestOperationsEnqueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := workqueue.NewDelayingQueue()
+	defer queue.ShutDown()
+	queue.Add("current_key")
+	key, _ := queue.Get()
+
+	ctrls := NewQueueOperationsCtx()
+	ctx = ctrls.WithValue(ctx, NewOperations("current_key", func() {
+		queue.Done(key)
+	}, func(duration time.Duration) {
+		queue.AddAfter(key, duration)
+	}, cancel).WithEnqueue(func(key string) {
+		queue.Add(key)
+	}))
+
+	handler.NewHandlerFromFunc(func(ctx context.Context) {
+		ctrls.Enqueue(ctx, "owner_key")
+		ctrls.Done(ctx)
+	}, "example").Handle(ctx)
+
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+	require.Equal(t, 1, queue.Len())
+	next, _ := queue.Get()
+	require.Equal(t, "owner_key", next)
+}
+
+func TestRequeueAPIErr(t *testing.T) {
+	tests := []struct {
+		name              string
+		err               error
+		wantRequeueAfters []time.Duration
+		wantDone          int
+	}{
+		{
+			name:              "retriable with delay",
+			err:               apierrors.NewTooManyRequests("slow down", 5),
+			wantRequeueAfters: []time.Duration{5 * time.Second},
+		},
+		{
+			name:              "retriable without delay",
+			err:               apierrors.NewInternalError(errors.New("oops")),
+			wantRequeueAfters: []time.Duration{0},
+		},
+		{
+			name:     "not retriable",
+			err:      apierrors.NewBadRequest("bad"),
+			wantDone: 1,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var done int
+			var requeueAfters []time.Duration
+			operations := NewOperations("current_key", func() {
+				done++
+			}, func(duration time.Duration) {
+				requeueAfters = append(requeueAfters, duration)
+			}, cancel)
+			ctrls := NewQueueOperationsCtx()
+			ctx = ctrls.WithValue(ctx, operations)
+
+			ctrls.RequeueAPIErr(ctx, tt.err)
+
+			require.Equal(t, tt.wantRequeueAfters, requeueAfters)
+			require.Equal(t, tt.wantDone, done)
+			require.Equal(t, tt.err, ctrls.Error(ctx))
+			require.ErrorIs(t, ctx.Err(), context.Canceled)
+		})
+	}
+}
+
+func TestOperationsLogDispositions(t *testing.T) {
+	apiErr := apierrors.NewTooManyRequests("slow down", 5)
+	tests := []struct {
+		name    string
+		operate func(ops *Operations)
+		want    map[string]any
+	}{
+		{
+			name:    "done",
+			operate: func(ops *Operations) { ops.Done() },
+			want:    map[string]any{"key": "current_key", "disposition": "done"},
+		},
+		{
+			name:    "requeue",
+			operate: func(ops *Operations) { ops.Requeue() },
+			want:    map[string]any{"key": "current_key", "disposition": "requeue", "delay": "0s"},
+		},
+		{
+			name:    "requeue after",
+			operate: func(ops *Operations) { ops.RequeueAfter(time.Second) },
+			want:    map[string]any{"key": "current_key", "disposition": "requeue", "delay": "1s"},
+		},
+		{
+			name:    "requeue err",
+			operate: func(ops *Operations) { ops.RequeueErr(errors.New("failed")) },
+			want:    map[string]any{"key": "current_key", "disposition": "requeue", "delay": "0s", "error": "failed"},
+		},
+		{
+			name:    "requeue api err",
+			operate: func(ops *Operations) { ops.RequeueAPIErr(apiErr) },
+			want:    map[string]any{"key": "current_key", "disposition": "requeue", "delay": "5s", "error": apiErr.Error()},
+		},
+		{
+			name:    "requeue api err not retriable",
+			operate: func(ops *Operations) { ops.RequeueAPIErr(apierrors.NewBadRequest("bad")) },
+			want:    map[string]any{"key": "current_key", "disposition": "done", "error": "bad"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var lines []map[string]any
+			logger := funcr.NewJSON(func(obj string) {
+				var line map[string]any
+				require.NoError(t, json.Unmarshal([]byte(obj), &line))
+				lines = append(lines, line)
+			}, funcr.Options{Verbosity: 4})
+
+			_, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ops := NewOperations("current_key", func() {}, func(_ time.Duration) {}, cancel).WithLogger(logger)
+			tt.operate(ops)
+
+			require.Len(t, lines, 1)
+			for k, v := range tt.want {
+				require.Equal(t, v, lines[0][k], k)
+			}
+			if _, ok := tt.want["error"]; !ok {
+				require.NotContains(t, lines[0], "error")
+			}
+		})
+	}
+}
+
+func TestOperationsContextDefaultsToContextLogger(t *testing.T) {
+	var lines []map[string]any
+	logger := funcr.NewJSON(func(obj string) {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal([]byte(obj), &line))
+		lines = append(lines, line)
+	}, funcr.Options{Verbosity: 4})
+
+	ctx, cancel := context.WithCancel(logr.NewContext(context.Background(), logger))
+	defer cancel()
+	queueOps := NewQueueOperationsCtx()
+	ctx = queueOps.WithValue(ctx, NewOperations("current_key", func() {}, func(_ time.Duration) {}, cancel))
+	queueOps.RequeueErr(ctx, errors.New("failed"))
+
+	require.Len(t, lines, 1)
+	require.Equal(t, "current_key", lines[0]["key"])
+	require.Equal(t, "failed", lines[0]["error"])
+}
@@ -0,0 +1,84 @@
+package fake
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Disposition is a queue operation that decides what happens to the current
+// key. Its value is the name of the corresponding FakeInterface method.
+type Disposition string
+
+const (
+	DispositionDone            Disposition = "Done"
+	DispositionRequeue         Disposition = "Requeue"
+	DispositionRequeueAfter    Disposition = "RequeueAfter"
+	DispositionRequeueErr      Disposition = "RequeueErr"
+	DispositionRequeueAfterErr Disposition = "RequeueAfterErr"
+	DispositionRequeueAPIErr   Disposition = "RequeueAPIErr"
+)
+
+// dispositions lists every Disposition, to find the ones that were called.
+var dispositions = []Disposition{
+	DispositionDone,
+	DispositionRequeue,
+	DispositionRequeueAfter,
+	DispositionRequeueErr,
+	DispositionRequeueAfterErr,
+	DispositionRequeueAPIErr,
+}
+
+// TestingT is the subset of testing.TB used by the assertion helpers, so
+// that they can be used with testing.T or testify-style mocks.
+type TestingT interface {
+	Errorf(format string, args ...any)
+}
+
+// AssertExactlyOneDisposition checks that exactly one disposition method
+// (Done, Requeue, RequeueAfter, RequeueErr, RequeueAfterErr, or
+// RequeueAPIErr) was called on fake, exactly once, and that it was
+// expected. It reports an error on t and returns false otherwise.
+func AssertExactlyOneDisposition(t TestingT, fake *FakeInterface, expected Disposition) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	invocations := fake.Invocations()
+	called := make([]string, 0)
+	for _, d := range dispositions {
+		for range invocations[string(d)] {
+			called = append(called, string(d))
+		}
+	}
+	sort.Strings(called)
+
+	switch {
+	case len(called) == 0:
+		t.Errorf("expected disposition %s, but no disposition was called", expected)
+		return false
+	case len(called) > 1:
+		t.Errorf("expected only disposition %s, but got %d: %s", expected, len(called), strings.Join(called, ", "))
+		return false
+	case called[0] != string(expected):
+		t.Errorf("expected disposition %s, but got %s", expected, called[0])
+		return false
+	}
+	return true
+}
+
+// AssertRequeueAfter checks that the only disposition called on fake was a
+// single RequeueAfter, with the expected duration. It reports an error on t
+// and returns false otherwise.
+func AssertRequeueAfter(t TestingT, fake *FakeInterface, expected time.Duration) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	if !AssertExactlyOneDisposition(t, fake, DispositionRequeueAfter) {
+		return false
+	}
+	if got := fake.RequeueAfterArgsForCall(0); got != expected {
+		t.Errorf("expected RequeueAfter(%s), but got RequeueAfter(%s)", expected, got)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue"
+)
+
+// recordingT records the errors reported by the assertion helpers.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertExactlyOneDisposition(t *testing.T) {
+	tests := []struct {
+		name        string
+		calls       func(fake *FakeInterface)
+		expected    Disposition
+		expectError string
+	}{
+		{
+			name:     "done",
+			calls:    func(fake *FakeInterface) { fake.Done() },
+			expected: DispositionDone,
+		},
+		{
+			name:     "requeue after",
+			calls:    func(fake *FakeInterface) { fake.RequeueAfter(time.Second) },
+			expected: DispositionRequeueAfter,
+		},
+		{
+			name:     "requeue api err",
+			calls:    func(fake *FakeInterface) { fake.RequeueAPIErr(errors.New("err")) },
+			expected: DispositionRequeueAPIErr,
+		},
+		{
+			name: "non-disposition calls are ignored",
+			calls: func(fake *FakeInterface) {
+				fake.Enqueue("other")
+				_ = fake.Error()
+				fake.RequeueErr(errors.New("err"))
+			},
+			expected: DispositionRequeueErr,
+		},
+		{
+			name:        "none",
+			calls:       func(_ *FakeInterface) {},
+			expected:    DispositionDone,
+			expectError: "expected disposition Done, but no disposition was called",
+		},
+		{
+			name:        "wrong disposition",
+			calls:       func(fake *FakeInterface) { fake.Requeue() },
+			expected:    DispositionDone,
+			expectError: "expected disposition Done, but got Requeue",
+		},
+		{
+			name: "multiple dispositions",
+			calls: func(fake *FakeInterface) {
+				fake.RequeueErr(errors.New("err"))
+				fake.Done()
+			},
+			expected:    DispositionDone,
+			expectError: "expected only disposition Done, but got 2: Done, RequeueErr",
+		},
+		{
+			name: "same disposition twice",
+			calls: func(fake *FakeInterface) {
+				fake.Done()
+				fake.Done()
+			},
+			expected:    DispositionDone,
+			expectError: "expected only disposition Done, but got 2: Done, Done",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &FakeInterface{}
+			tt.calls(fake)
+			rt := &recordingT{}
+			ok := AssertExactlyOneDisposition(rt, fake, tt.expected)
+			if tt.expectError == "" {
+				require.True(t, ok)
+				require.Empty(t, rt.errors)
+				return
+			}
+			require.False(t, ok)
+			require.Equal(t, []string{tt.expectError}, rt.errors)
+		})
+	}
+}
+
+func TestAssertRequeueAfter(t *testing.T) {
+	queueOps := queue.NewQueueOperationsCtx()
+	waitHandler := handler.NewHandlerFromFunc(func(ctx context.Context) {
+		queueOps.RequeueAfter(ctx, 30*time.Second)
+	}, "wait")
+
+	fake := &FakeInterface{}
+	waitHandler.Handle(queueOps.WithValue(context.Background(), fake))
+	require.Equal(t, 30*time.Second, fake.RequeueAfterArgsForCall(0))
+	require.True(t, AssertRequeueAfter(t, fake, 30*time.Second))
+
+	rt := &recordingT{}
+	require.False(t, AssertRequeueAfter(rt, fake, time.Minute))
+	require.Equal(t, []string{"expected RequeueAfter(1m0s), but got RequeueAfter(30s)"}, rt.errors)
+
+	rt = &recordingT{}
+	fake = &FakeInterface{}
+	fake.Requeue()
+	require.False(t, AssertRequeueAfter(rt, fake, time.Minute))
+	require.Equal(t, []string{"expected disposition RequeueAfter, but got Requeue"}, rt.errors)
+}
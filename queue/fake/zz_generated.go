@@ -13,6 +13,11 @@ type FakeInterface struct {
 	doneMutex       sync.RWMutex
 	doneArgsForCall []struct {
 	}
+	EnqueueStub        func(string)
+	enqueueMutex       sync.RWMutex
+	enqueueArgsForCall []struct {
+		arg1 string
+	}
 	ErrorStub        func() error
 	errorMutex       sync.RWMutex
 	errorArgsForCall []struct {
@@ -37,6 +42,12 @@ type FakeInterface struct {
 	requeueAfterArgsForCall []struct {
 		arg1 time.Duration
 	}
+	RequeueAfterErrStub        func(error, time.Duration)
+	requeueAfterErrMutex       sync.RWMutex
+	requeueAfterErrArgsForCall []struct {
+		arg1 error
+		arg2 time.Duration
+	}
 	RequeueErrStub        func(error)
 	requeueErrMutex       sync.RWMutex
 	requeueErrArgsForCall []struct {
@@ -70,6 +81,38 @@ func (fake *FakeInterface) DoneCalls(stub func()) {
 	fake.DoneStub = stub
 }
 
+func (fake *FakeInterface) Enqueue(arg1 string) {
+	fake.enqueueMutex.Lock()
+	fake.enqueueArgsForCall = append(fake.enqueueArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.EnqueueStub
+	fake.recordInvocation("Enqueue", []interface{}{arg1})
+	fake.enqueueMutex.Unlock()
+	if stub != nil {
+		fake.EnqueueStub(arg1)
+	}
+}
+
+func (fake *FakeInterface) EnqueueCallCount() int {
+	fake.enqueueMutex.RLock()
+	defer fake.enqueueMutex.RUnlock()
+	return len(fake.enqueueArgsForCall)
+}
+
+func (fake *FakeInterface) EnqueueCalls(stub func(string)) {
+	fake.enqueueMutex.Lock()
+	defer fake.enqueueMutex.Unlock()
+	fake.EnqueueStub = stub
+}
+
+func (fake *FakeInterface) EnqueueArgsForCall(i int) string {
+	fake.enqueueMutex.RLock()
+	defer fake.enqueueMutex.RUnlock()
+	argsForCall := fake.enqueueArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeInterface) Error() error {
 	fake.errorMutex.Lock()
 	ret, specificReturn := fake.errorReturnsOnCall[len(fake.errorArgsForCall)]
@@ -211,6 +254,39 @@ func (fake *FakeInterface) RequeueAfterArgsForCall(i int) time.Duration {
 	return argsForCall.arg1
 }
 
+func (fake *FakeInterface) RequeueAfterErr(arg1 error, arg2 time.Duration) {
+	fake.requeueAfterErrMutex.Lock()
+	fake.requeueAfterErrArgsForCall = append(fake.requeueAfterErrArgsForCall, struct {
+		arg1 error
+		arg2 time.Duration
+	}{arg1, arg2})
+	stub := fake.RequeueAfterErrStub
+	fake.recordInvocation("RequeueAfterErr", []interface{}{arg1, arg2})
+	fake.requeueAfterErrMutex.Unlock()
+	if stub != nil {
+		fake.RequeueAfterErrStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeInterface) RequeueAfterErrCallCount() int {
+	fake.requeueAfterErrMutex.RLock()
+	defer fake.requeueAfterErrMutex.RUnlock()
+	return len(fake.requeueAfterErrArgsForCall)
+}
+
+func (fake *FakeInterface) RequeueAfterErrCalls(stub func(error, time.Duration)) {
+	fake.requeueAfterErrMutex.Lock()
+	defer fake.requeueAfterErrMutex.Unlock()
+	fake.RequeueAfterErrStub = stub
+}
+
+func (fake *FakeInterface) RequeueAfterErrArgsForCall(i int) (error, time.Duration) {
+	fake.requeueAfterErrMutex.RLock()
+	defer fake.requeueAfterErrMutex.RUnlock()
+	argsForCall := fake.requeueAfterErrArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeInterface) RequeueErr(arg1 error) {
 	fake.requeueErrMutex.Lock()
 	fake.requeueErrArgsForCall = append(fake.requeueErrArgsForCall, struct {
@@ -248,6 +324,8 @@ func (fake *FakeInterface) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.doneMutex.RLock()
 	defer fake.doneMutex.RUnlock()
+	fake.enqueueMutex.RLock()
+	defer fake.enqueueMutex.RUnlock()
 	fake.errorMutex.RLock()
 	defer fake.errorMutex.RUnlock()
 	fake.requeueMutex.RLock()
@@ -256,6 +334,8 @@ func (fake *FakeInterface) Invocations() map[string][][]interface{} {
 	defer fake.requeueAPIErrMutex.RUnlock()
 	fake.requeueAfterMutex.RLock()
 	defer fake.requeueAfterMutex.RUnlock()
+	fake.requeueAfterErrMutex.RLock()
+	defer fake.requeueAfterErrMutex.RUnlock()
 	fake.requeueErrMutex.RLock()
 	defer fake.requeueErrMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
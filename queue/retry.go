@@ -1,15 +1,46 @@
 package queue
 
 import (
+	"sync"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 )
 
+// RetryClassifier decides whether err is transient. If the classifier
+// doesn't recognize err it returns matched=false, and the next classifier (or
+// the default kube API error handling) is consulted instead.
+type RetryClassifier func(err error) (retry bool, after time.Duration, matched bool)
+
+var (
+	retryClassifiersLock sync.RWMutex
+	retryClassifiers     []RetryClassifier
+)
+
+// RegisterRetryClassifier adds a classifier that ShouldRetry (and so
+// RequeueAPIErr) consults before falling back to the default handling of
+// kube API errors. Classifiers are consulted in the order they're registered,
+// and the first to match decides. This is typically called from an init
+// function, to teach the queue about errors from other APIs.
+func RegisterRetryClassifier(classifier RetryClassifier) {
+	retryClassifiersLock.Lock()
+	defer retryClassifiersLock.Unlock()
+	retryClassifiers = append(retryClassifiers, classifier)
+}
+
 // ShouldRetry returns true if the error is transient.
 // It returns a delay if the server suggested one.
 func ShouldRetry(err error) (bool, time.Duration) {
+	retryClassifiersLock.RLock()
+	classifiers := retryClassifiers
+	retryClassifiersLock.RUnlock()
+	for _, classify := range classifiers {
+		if retry, after, matched := classify(err); matched {
+			return retry, after
+		}
+	}
+
 	if seconds, shouldRetry := apierrors.SuggestsClientDelay(err); shouldRetry {
 		return true, time.Duration(seconds) * time.Second
 	}
@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func grpcUnavailable(err error) (bool, time.Duration, bool) {
+	if status.Code(err) != codes.Unavailable {
+		return false, 0, false
+	}
+	return true, 3 * time.Second, true
+}
+
+func TestRetryClassifier(t *testing.T) {
+	t.Cleanup(func() { retryClassifiers = nil })
+	RegisterRetryClassifier(grpcUnavailable)
+
+	tests := []struct {
+		name      string
+		err       error
+		wantRetry bool
+		wantAfter time.Duration
+	}{
+		{
+			name:      "matched by classifier",
+			err:       status.Error(codes.Unavailable, "spicedb is unavailable"),
+			wantRetry: true,
+			wantAfter: 3 * time.Second,
+		},
+		{
+			name: "not matched by classifier",
+			err:  status.Error(codes.InvalidArgument, "bad request"),
+		},
+		{
+			name:      "falls back to api errors",
+			err:       apierrors.NewTooManyRequests("slow down", 2),
+			wantRetry: true,
+			wantAfter: 2 * time.Second,
+		},
+		{
+			name:      "falls back to transient api errors",
+			err:       apierrors.NewInternalError(errors.New("oops")),
+			wantRetry: true,
+		},
+		{
+			name: "falls back to permanent api errors",
+			err:  apierrors.NewNotFound(schema.GroupResource{Resource: "things"}, "thing"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			retry, after := ShouldRetry(tt.err)
+			require.Equal(t, tt.wantRetry, retry)
+			require.Equal(t, tt.wantAfter, after)
+		})
+	}
+}
+
+func TestRequeueAPIErrClassifier(t *testing.T) {
+	t.Cleanup(func() { retryClassifiers = nil })
+	RegisterRetryClassifier(grpcUnavailable)
+
+	_, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var done int
+	var requeuedAfter []time.Duration
+	operations := NewOperations("current_key", func() {
+		done++
+	}, func(duration time.Duration) {
+		requeuedAfter = append(requeuedAfter, duration)
+	}, cancel)
+
+	err := status.Error(codes.Unavailable, "spicedb is unavailable")
+	operations.RequeueAPIErr(err)
+	require.Equal(t, []time.Duration{3 * time.Second}, requeuedAfter)
+	require.Zero(t, done)
+	require.Equal(t, err, operations.Error())
+}
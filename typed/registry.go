@@ -12,11 +12,15 @@ package typed
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
@@ -63,15 +67,58 @@ func (k RegistryKey) String() string {
 type Registry struct {
 	sync.RWMutex
 	factories map[any]dynamicinformer.DynamicSharedInformerFactory
+
+	// startLock serializes informer creation and factory starts in
+	// EnsureStarted and StartAll, and guards stops
+	startLock sync.Mutex
+	// stops holds the stop channels owned by the registry for each started
+	// factory, so that RemoveAndStop can stop its informers
+	stops map[FactoryKey]*factoryStop
+}
+
+// factoryStop is closed by RemoveAndStop to stop a factory's informers.
+// Informers are started with a channel from merged, which closes when either
+// stop or the stop channel passed by the caller that started them closes.
+type factoryStop struct {
+	stop   chan struct{}
+	merged map[<-chan struct{}]chan struct{}
 }
 
 // NewRegistry returns a new, empty Registry
 func NewRegistry() *Registry {
 	return &Registry{
 		factories: make(map[any]dynamicinformer.DynamicSharedInformerFactory),
+		stops:     make(map[FactoryKey]*factoryStop),
 	}
 }
 
+// stopChFor returns a channel to start the factory for key with, which is
+// closed when stopCh is closed or when the factory is removed with
+// RemoveAndStop. It must be called with startLock held.
+func (r *Registry) stopChFor(key FactoryKey, stopCh <-chan struct{}) <-chan struct{} {
+	fs, ok := r.stops[key]
+	if !ok {
+		fs = &factoryStop{
+			stop:   make(chan struct{}),
+			merged: make(map[<-chan struct{}]chan struct{}),
+		}
+		r.stops[key] = fs
+	}
+	if merged, ok := fs.merged[stopCh]; ok {
+		return merged
+	}
+	merged := make(chan struct{})
+	fs.merged[stopCh] = merged
+	go func() {
+		defer close(merged)
+		select {
+		case <-stopCh:
+		case <-fs.stop:
+		}
+	}()
+	return merged
+}
+
 // MustNewFilteredDynamicSharedInformerFactory creates a new SharedInformerFactory
 // and registers it under the given FactoryKey. It panics if there is already
 // an entry with that key.
@@ -113,6 +160,33 @@ func ListerForKey[K runtime.Object](r *Registry, key RegistryKey) (*Lister[K], e
 	return NewLister[K](lister), nil
 }
 
+// GetTyped returns the object named by nn from the cache for key in a
+// Registry. An empty namespace gets a cluster-scoped object.
+func GetTyped[K runtime.Object](r *Registry, key RegistryKey, nn types.NamespacedName) (K, error) {
+	lister, err := ListerForKey[K](r, key)
+	if err != nil {
+		var nilObj K
+		return nilObj, err
+	}
+	if nn.Namespace == metav1.NamespaceNone {
+		return lister.Get(nn.Name)
+	}
+	return lister.ByNamespace(nn.Namespace).Get(nn.Name)
+}
+
+// ListTyped returns the objects matching selector in namespace from the cache
+// for key in a Registry. An empty namespace lists across all namespaces.
+func ListTyped[K runtime.Object](r *Registry, key RegistryKey, namespace string, selector labels.Selector) ([]K, error) {
+	lister, err := ListerForKey[K](r, key)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == metav1.NamespaceAll {
+		return lister.List(selector)
+	}
+	return lister.ByNamespace(namespace).List(selector)
+}
+
 // IndexerFor returns a typed Indexer from a Registry
 // Deprecated: Use MustIndexerForKey instead
 func IndexerFor[K runtime.Object](r *Registry, key RegistryKey) *Indexer[K] {
@@ -149,8 +223,54 @@ func (r *Registry) Add(key FactoryKey, factory dynamicinformer.DynamicSharedInfo
 // context cancellation.
 func (r *Registry) Remove(key FactoryKey) {
 	r.Lock()
-	defer r.Unlock()
 	delete(r.factories, key)
+	r.Unlock()
+
+	// forget, but don't close, the registry's stop channel, so that a factory
+	// added later under the same key gets its own
+	r.startLock.Lock()
+	delete(r.stops, key)
+	r.startLock.Unlock()
+}
+
+// RemoveAndStop removes a factory from the registry, stops the informers
+// started from it with EnsureStarted or StartAll, and shuts it down, so that
+// no new informers can be started from it. The caller doesn't need to close
+// the stop channel passed to EnsureStarted or StartAll first.
+// Shutdown blocks until all informers started by the factory have stopped, so
+// informers started by calling the factory's Start directly still require the
+// stop channel passed to it to be closed.
+func (r *Registry) RemoveAndStop(key FactoryKey) {
+	r.Lock()
+	factory, ok := r.factories[key]
+	delete(r.factories, key)
+	r.Unlock()
+
+	r.startLock.Lock()
+	if fs, started := r.stops[key]; started {
+		close(fs.stop)
+		delete(r.stops, key)
+	}
+	r.startLock.Unlock()
+
+	if ok {
+		factory.Shutdown()
+	}
+}
+
+// Keys returns a snapshot of the FactoryKeys registered in the registry,
+// in sorted order.
+func (r *Registry) Keys() []FactoryKey {
+	r.RLock()
+	defer r.RUnlock()
+	keys := make([]FactoryKey, 0, len(r.factories))
+	for k := range r.factories {
+		keys = append(keys, k.(FactoryKey))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	return keys
 }
 
 // InformerFactoryFor returns GVR-specific InformerFactory from the Registry.
@@ -225,6 +345,57 @@ func (r *Registry) InformerForKey(key RegistryKey) (cache.SharedIndexInformer, e
 	return factory.Informer(), nil
 }
 
+// EnsureStarted returns the GVR-specific Informer from the Registry, creating
+// it if needed, starting its factory if it isn't already running, and
+// waiting for its cache to sync. It is safe to call concurrently for the same
+// key; only the first caller will start the informer.
+func (r *Registry) EnsureStarted(key RegistryKey, stopCh <-chan struct{}) (cache.SharedIndexInformer, error) {
+	r.RLock()
+	factory, ok := r.factories[key.FactoryKey]
+	r.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("EnsureStarted called with unknown key %s", key)
+	}
+
+	r.startLock.Lock()
+	informer := factory.ForResource(key.GroupVersionResource).Informer()
+	stopCh = r.stopChFor(key.FactoryKey, stopCh)
+	factory.Start(stopCh)
+	r.startLock.Unlock()
+
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		return nil, fmt.Errorf("cache for %s did not sync", key)
+	}
+	return informer, nil
+}
+
+// StartAll starts every factory in the Registry and waits for the caches of
+// the informers requested from them so far to sync. It returns the
+// WaitForCacheSync results for each factory; a cache that failed to sync
+// before stopCh was closed is reported as false.
+func (r *Registry) StartAll(stopCh <-chan struct{}) map[FactoryKey]map[schema.GroupVersionResource]bool {
+	r.RLock()
+	factories := make(map[FactoryKey]dynamicinformer.DynamicSharedInformerFactory, len(r.factories))
+	for k, f := range r.factories {
+		factories[k.(FactoryKey)] = f
+	}
+	r.RUnlock()
+
+	factoryStopChs := make(map[FactoryKey]<-chan struct{}, len(factories))
+	r.startLock.Lock()
+	for key, factory := range factories {
+		factoryStopChs[key] = r.stopChFor(key, stopCh)
+		factory.Start(factoryStopChs[key])
+	}
+	r.startLock.Unlock()
+
+	synced := make(map[FactoryKey]map[schema.GroupVersionResource]bool, len(factories))
+	for key, factory := range factories {
+		synced[key] = factory.WaitForCacheSync(factoryStopChs[key])
+	}
+	return synced
+}
+
 // IndexerFor returns the GVR-specific Indexer from the Registry
 // Deprecated: use MustIndexerForKey instead.
 func (r *Registry) IndexerFor(key RegistryKey) cache.Indexer {
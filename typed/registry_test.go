@@ -3,12 +3,19 @@ package typed
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/tools/cache"
 )
@@ -243,3 +250,204 @@ func TestForKey(t *testing.T) {
 	_, err = IndexerForKey[*corev1.Pod](registry, badKey)
 	require.Error(t, err)
 }
+
+func TestKeys(t *testing.T) {
+	registry := NewRegistry()
+	require.Empty(t, registry.Keys())
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	firstKey := NewFactoryKey("my-controller", "localCluster", "first")
+	secondKey := NewFactoryKey("my-controller", "localCluster", "second")
+	registry.MustNewFilteredDynamicSharedInformerFactory(secondKey, client, 0, metav1.NamespaceAll, nil)
+	registry.MustNewFilteredDynamicSharedInformerFactory(firstKey, client, 0, metav1.NamespaceAll, nil)
+
+	require.Equal(t, []FactoryKey{firstKey, secondKey}, registry.Keys())
+
+	registry.Remove(firstKey)
+	require.Equal(t, []FactoryKey{secondKey}, registry.Keys())
+}
+
+type shutdownRecordingFactory struct {
+	dynamicinformer.DynamicSharedInformerFactory
+	shutdownCalls int
+}
+
+func (f *shutdownRecordingFactory) Shutdown() {
+	f.shutdownCalls++
+	f.DynamicSharedInformerFactory.Shutdown()
+}
+
+func TestRemoveAndStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	secretGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := fake.NewSimpleDynamicClient(scheme)
+	registry := NewRegistry()
+
+	dependentObjectKey := NewFactoryKey("my-controller", "localCluster", "dependentObjects")
+	factory := &shutdownRecordingFactory{
+		DynamicSharedInformerFactory: dynamicinformer.NewDynamicSharedInformerFactory(client, 0),
+	}
+	require.NoError(t, registry.Add(dependentObjectKey, factory))
+	factory.ForResource(secretGVR)
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	// informers stop once the context is cancelled, which unblocks shutdown
+	cancel()
+	registry.RemoveAndStop(dependentObjectKey)
+
+	require.Equal(t, 1, factory.shutdownCalls)
+	require.Empty(t, registry.Keys())
+	_, err := registry.InformerFactoryForKey(NewRegistryKey(dependentObjectKey, secretGVR))
+	require.Error(t, err)
+
+	// removing an unknown key is a no-op
+	registry.RemoveAndStop(dependentObjectKey)
+	require.Equal(t, 1, factory.shutdownCalls)
+}
+
+func TestRemoveAndStopWithoutCancel(t *testing.T) {
+	secretGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := fake.NewSimpleDynamicClient(scheme)
+	registry := NewRegistry()
+
+	factoryKey := NewFactoryKey("my-controller", "localCluster", "secrets")
+	registry.MustNewFilteredDynamicSharedInformerFactory(factoryKey, client, 0, metav1.NamespaceAll, nil)
+
+	// the caller never closes its stop channel
+	neverStop := make(chan struct{})
+	informer, err := registry.EnsureStarted(NewRegistryKey(factoryKey, secretGVR), neverStop)
+	require.NoError(t, err)
+	require.True(t, informer.HasSynced())
+
+	stopped := make(chan struct{})
+	go func() {
+		registry.RemoveAndStop(factoryKey)
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "RemoveAndStop did not return")
+	}
+	require.True(t, informer.IsStopped())
+	require.Empty(t, registry.Keys())
+}
+
+func TestGetAndListTyped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	secretGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := fake.NewSimpleDynamicClient(scheme,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "one", Labels: map[string]string{"app": "x"}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "two"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "b", Name: "three", Labels: map[string]string{"app": "x"}}},
+	)
+	registry := NewRegistry()
+	factoryKey := NewFactoryKey("my-controller", "localCluster", "secrets")
+	factory := registry.MustNewFilteredDynamicSharedInformerFactory(factoryKey, client, 0, metav1.NamespaceAll, nil)
+	factory.ForResource(secretGVR)
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	key := NewRegistryKey(factoryKey, secretGVR)
+
+	secret, err := GetTyped[*corev1.Secret](registry, key, types.NamespacedName{Namespace: "a", Name: "one"})
+	require.NoError(t, err)
+	require.Equal(t, "one", secret.GetName())
+
+	_, err = GetTyped[*corev1.Secret](registry, key, types.NamespacedName{Namespace: "a", Name: "missing"})
+	require.True(t, apierrors.IsNotFound(err))
+
+	secrets, err := ListTyped[*corev1.Secret](registry, key, "a", labels.Everything())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"one", "two"}, secretNames(secrets))
+
+	secrets, err = ListTyped[*corev1.Secret](registry, key, metav1.NamespaceAll, labels.SelectorFromSet(labels.Set{"app": "x"}))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"one", "three"}, secretNames(secrets))
+
+	badKey := NewRegistryKey(NewFactoryKey("other-controller", "othercluster", "secrets"), secretGVR)
+	_, err = GetTyped[*corev1.Secret](registry, badKey, types.NamespacedName{Namespace: "a", Name: "one"})
+	require.Error(t, err)
+	_, err = ListTyped[*corev1.Secret](registry, badKey, "a", labels.Everything())
+	require.Error(t, err)
+}
+
+func TestEnsureStarted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	secretGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := fake.NewSimpleDynamicClient(scheme,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "example", Name: "mysecret"}},
+	)
+	registry := NewRegistry()
+	factoryKey := NewFactoryKey("my-controller", "localCluster", "secrets")
+	registry.MustNewFilteredDynamicSharedInformerFactory(factoryKey, client, 0, metav1.NamespaceAll, nil)
+	key := NewRegistryKey(factoryKey, secretGVR)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	informers := make([]cache.SharedIndexInformer, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			informers[i], errs[i] = registry.EnsureStarted(key, ctx.Done())
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < callers; i++ {
+		require.NoError(t, errs[i])
+		require.Same(t, informers[0], informers[i])
+		require.True(t, informers[i].HasSynced())
+	}
+	require.Len(t, informers[0].GetStore().List(), 1)
+
+	_, err := registry.EnsureStarted(NewRegistryKey(NewFactoryKey("other-controller", "othercluster", "secrets"), secretGVR), ctx.Done())
+	require.Error(t, err)
+}
+
+func TestStartAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	secretGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	configMapGVR := corev1.SchemeGroupVersion.WithResource("configmaps")
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := fake.NewSimpleDynamicClient(scheme,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "example", Name: "mysecret"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "example", Name: "myconfigmap"}},
+	)
+	registry := NewRegistry()
+	secretsKey := NewFactoryKey("my-controller", "localCluster", "secrets")
+	registry.MustNewFilteredDynamicSharedInformerFactory(secretsKey, client, 0, metav1.NamespaceAll, nil).ForResource(secretGVR)
+	configMapsKey := NewFactoryKey("other-controller", "localCluster", "configmaps")
+	registry.MustNewFilteredDynamicSharedInformerFactory(configMapsKey, client, 0, metav1.NamespaceAll, nil).ForResource(configMapGVR)
+
+	require.Equal(t, map[FactoryKey]map[schema.GroupVersionResource]bool{
+		secretsKey:    {secretGVR: true},
+		configMapsKey: {configMapGVR: true},
+	}, registry.StartAll(ctx.Done()))
+
+	secrets, err := ListTyped[*corev1.Secret](registry, NewRegistryKey(secretsKey, secretGVR), metav1.NamespaceAll, labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	configMaps, err := ListTyped[*corev1.ConfigMap](registry, NewRegistryKey(configMapsKey, configMapGVR), metav1.NamespaceAll, labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, configMaps, 1)
+}
@@ -0,0 +1,47 @@
+package typed
+
+import (
+	"context"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue"
+)
+
+// CacheSyncedHandler requeues the current key until the informers for all
+// of its keys have synced, so that a controller doesn't reconcile based on
+// a partially filled cache right after startup. Once the caches have synced
+// it calls Next.
+//
+// The informers should already be started (i.e. via Registry.StartAll or
+// Registry.EnsureStarted); an informer that was never started never syncs.
+type CacheSyncedHandler struct {
+	ctrls    queue.OperationsContext
+	registry *Registry
+	keys     []RegistryKey
+	next     handler.ContextHandler
+}
+
+// NewCacheSyncedHandler creates a new CacheSyncedHandler.
+func NewCacheSyncedHandler(ctrls queue.OperationsContext, registry *Registry, keys []RegistryKey, next handler.ContextHandler) *CacheSyncedHandler {
+	return &CacheSyncedHandler{
+		ctrls:    ctrls,
+		registry: registry,
+		keys:     keys,
+		next:     next,
+	}
+}
+
+func (h *CacheSyncedHandler) Handle(ctx context.Context) {
+	for _, key := range h.keys {
+		informer, err := h.registry.InformerForKey(key)
+		if err != nil {
+			h.ctrls.RequeueErr(ctx, err)
+			return
+		}
+		if !informer.HasSynced() {
+			h.ctrls.Requeue(ctx)
+			return
+		}
+	}
+	h.next.Handle(ctx)
+}
@@ -0,0 +1,76 @@
+package typed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue"
+	queuefake "github.com/authzed/controller-idioms/queue/fake"
+)
+
+func TestCacheSyncedHandler(t *testing.T) {
+	secretGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := fake.NewSimpleDynamicClient(scheme)
+
+	tests := []struct {
+		name           string
+		start          bool
+		key            RegistryKey
+		wantNext       bool
+		wantRequeue    int
+		wantRequeueErr int
+	}{
+		{
+			name:        "unsynced",
+			key:         NewRegistryKey("my-controller", secretGVR),
+			wantRequeue: 1,
+		},
+		{
+			name:     "synced",
+			start:    true,
+			key:      NewRegistryKey("my-controller", secretGVR),
+			wantNext: true,
+		},
+		{
+			name:           "unknown key",
+			key:            NewRegistryKey("other-controller", secretGVR),
+			wantRequeueErr: 1,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			registry := NewRegistry()
+			registry.MustNewFilteredDynamicSharedInformerFactory("my-controller", client, 0, metav1.NamespaceAll, nil)
+			if tt.start {
+				_, err := registry.EnsureStarted(tt.key, ctx.Done())
+				require.NoError(t, err)
+			}
+
+			ctrls := &queuefake.FakeInterface{}
+			operations := queue.NewQueueOperationsCtx()
+			ctx = operations.WithValue(ctx, ctrls)
+
+			nextCalled := false
+			NewCacheSyncedHandler(operations, registry, []RegistryKey{tt.key}, handler.ContextHandlerFunc(func(_ context.Context) {
+				nextCalled = true
+			})).Handle(ctx)
+
+			require.Equal(t, tt.wantNext, nextCalled)
+			require.Equal(t, tt.wantRequeue, ctrls.RequeueCallCount())
+			require.Equal(t, tt.wantRequeueErr, ctrls.RequeueErrCallCount())
+		})
+	}
+}
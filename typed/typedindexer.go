@@ -116,3 +116,31 @@ func IndexerListToTypedList[K runtime.Object](objs []any) []K {
 	}
 	return typedObjs
 }
+
+// ByIndexTyped queries a cache.Indexer by index and returns the matching
+// objects as K. Unlike Indexer.ByIndex, the objects in the indexer may be
+// either unstructured (as stored by dynamic informers) or already of type K,
+// and any object that can't be converted results in an error.
+func ByIndexTyped[K runtime.Object](indexer cache.Indexer, indexName, indexValue string) ([]K, error) {
+	objs, err := indexer.ByIndex(indexName, indexValue)
+	if err != nil {
+		return nil, err
+	}
+	typedObjs := make([]K, 0, len(objs))
+	for _, obj := range objs {
+		if typedObj, ok := obj.(K); ok {
+			typedObjs = append(typedObjs, typedObj)
+			continue
+		}
+		rObj, ok := obj.(runtime.Object)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a runtime.Object", obj)
+		}
+		typedObj, err := UnstructuredObjToTypedObj[K](rObj)
+		if err != nil {
+			return nil, fmt.Errorf("list conversion error: %w", err)
+		}
+		typedObjs = append(typedObjs, typedObj)
+	}
+	return typedObjs, nil
+}
@@ -3,11 +3,15 @@ package typed
 import (
 	"context"
 	"fmt"
+	"testing"
 
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
 )
 
 func ExampleIndexer() {
@@ -25,3 +29,72 @@ func ExampleIndexer() {
 	fmt.Printf("%T", secrets)
 	// Output: []*v1.Secret
 }
+
+func TestByIndexTyped(t *testing.T) {
+	const indexName = "owner"
+	ownerIndexFunc := func(obj interface{}) ([]string, error) {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return nil, err
+		}
+		if key == "example/unowned" {
+			return nil, nil
+		}
+		return []string{"owner"}, nil
+	}
+	secrets := []runtime.Object{
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "example", Name: "a"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "example", Name: "b"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "example", Name: "unowned"}},
+	}
+
+	t.Run("unstructured", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		scheme := runtime.NewScheme()
+		require.NoError(t, corev1.AddToScheme(scheme))
+		client := fake.NewSimpleDynamicClient(scheme, secrets...)
+		informerFactory := dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
+		informer := informerFactory.ForResource(corev1.SchemeGroupVersion.WithResource("secrets")).Informer()
+		require.NoError(t, informer.AddIndexers(cache.Indexers{indexName: ownerIndexFunc}))
+		informerFactory.Start(ctx.Done())
+		informerFactory.WaitForCacheSync(ctx.Done())
+
+		got, err := ByIndexTyped[*corev1.Secret](informer.GetIndexer(), indexName, "owner")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"a", "b"}, secretNames(got))
+	})
+
+	t.Run("typed", func(t *testing.T) {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{indexName: ownerIndexFunc})
+		for _, s := range secrets {
+			require.NoError(t, indexer.Add(s))
+		}
+
+		got, err := ByIndexTyped[*corev1.Secret](indexer, indexName, "owner")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"a", "b"}, secretNames(got))
+	})
+
+	t.Run("unknown index", func(t *testing.T) {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		_, err := ByIndexTyped[*corev1.Secret](indexer, indexName, "owner")
+		require.Error(t, err)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{indexName: ownerIndexFunc})
+		require.NoError(t, indexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "example", Name: "a"}}))
+		_, err := ByIndexTyped[*corev1.Secret](indexer, indexName, "owner")
+		require.Error(t, err)
+	})
+}
+
+func secretNames(secrets []*corev1.Secret) []string {
+	names := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		names = append(names, s.GetName())
+	}
+	return names
+}
@@ -21,6 +21,8 @@ func NewLister[K runtime.Object](lister cache.GenericLister) *Lister[K] {
 	return &Lister[K]{lister: lister}
 }
 
+// List returns the objects matching selector, converted to K. It returns an
+// error if any object can't be converted.
 func (t Lister[K]) List(selector labels.Selector) (ret []K, err error) {
 	objs, err := t.lister.List(selector)
 	if err != nil {
@@ -53,6 +55,8 @@ type NamespaceLister[K runtime.Object] struct {
 	lister cache.GenericNamespaceLister
 }
 
+// List returns the objects in the namespace matching selector, converted to
+// K. It returns an error if any object can't be converted.
 func (t NamespaceLister[K]) List(selector labels.Selector) (ret []K, err error) {
 	objs, err := t.lister.List(selector)
 	if err != nil {
@@ -86,8 +90,12 @@ func UnstructuredListToTypeList[K runtime.Object](objs []runtime.Object) ([]K, e
 }
 
 // UnstructuredObjToTypedObj is a helper that converts an unstructured object
-// to a particular type.
+// to a particular type. Objects that are already of that type are returned
+// as-is.
 func UnstructuredObjToTypedObj[K runtime.Object](obj runtime.Object) (K, error) {
+	if typed, ok := obj.(K); ok {
+		return typed, nil
+	}
 	var typedObj *K
 	u, ok := obj.(*unstructured.Unstructured)
 	if !ok {
@@ -3,11 +3,16 @@ package typed
 import (
 	"context"
 	"fmt"
+	"testing"
 
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
 )
 
 func ExampleLister() {
@@ -25,3 +30,59 @@ func ExampleLister() {
 	fmt.Printf("%T", secret)
 	// Output: *v1.Secret
 }
+
+func TestListerList(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := fake.NewSimpleDynamicClient(scheme,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "one", Labels: map[string]string{"app": "x"}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "two", Labels: map[string]string{"app": "y"}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "b", Name: "three", Labels: map[string]string{"app": "x"}}},
+	)
+	informerFactory := dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
+	lister := NewLister[*corev1.Secret](informerFactory.ForResource(corev1.SchemeGroupVersion.WithResource("secrets")).Lister())
+	informerFactory.Start(ctx.Done())
+	informerFactory.WaitForCacheSync(ctx.Done())
+
+	selector := labels.SelectorFromSet(labels.Set{"app": "x"})
+	secrets, err := lister.List(selector)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"one", "three"}, secretNames(secrets))
+
+	secrets, err = lister.ByNamespace("a").List(selector)
+	require.NoError(t, err)
+	require.Equal(t, []string{"one"}, secretNames(secrets))
+	require.Equal(t, "a", secrets[0].Namespace)
+}
+
+func TestListerListConversionError(t *testing.T) {
+	// an object of another type in the cache can't be converted
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "one"}}))
+	lister := NewLister[*corev1.Secret](cache.NewGenericLister(indexer, corev1.Resource("secrets")))
+
+	_, err := lister.List(labels.Everything())
+	require.Error(t, err)
+	_, err = lister.ByNamespace("a").List(labels.Everything())
+	require.Error(t, err)
+}
+
+func TestUnstructuredObjToTypedObj(t *testing.T) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "one"}}
+	u, err := ObjToUnstructuredObj(secret)
+	require.NoError(t, err)
+
+	fromUnstructured, err := UnstructuredObjToTypedObj[*corev1.Secret](u)
+	require.NoError(t, err)
+	require.Equal(t, "one", fromUnstructured.Name)
+
+	fromTyped, err := UnstructuredObjToTypedObj[*corev1.Secret](secret)
+	require.NoError(t, err)
+	require.Same(t, secret, fromTyped)
+
+	_, err = UnstructuredObjToTypedObj[*corev1.Secret](&corev1.ConfigMap{})
+	require.Error(t, err)
+}
@@ -54,11 +54,15 @@
 //			// now this succeeds, and returns the unboxed value
 //			CtxExpensiveObject.MustValue(ctx)
 //		}
+//
+// For debugging, keys can be given a name with `Named`; `Dump` then returns
+// the values of all named keys that are set in a context.
 package typedctx
 
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/authzed/controller-idioms/handler"
 )
@@ -75,9 +79,22 @@ type MustValueContext[V any] interface {
 	MustValue(ctx context.Context) V
 }
 
+var (
+	_ handler.ContextKey = &Key[any]{}
+	_ handler.ContextKey = &DefaultingKey[any]{}
+	_ handler.ContextKey = &BoxedKey[any]{}
+	_ handler.ContextKey = &LazyBoxedKey[any]{}
+	_ handler.ContextKey = &SliceKey[any]{}
+	_ handler.ContextKey = &MapKey[string, any]{}
+)
+
 // Key is a type that is used as a key in a context.Context for a
 // specific type of value V. It mimics the context.Context interface
-type Key[V any] struct{}
+type Key[V any] struct {
+	// a non-zero size ensures each NewKey returns a distinct pointer;
+	// pointers to zero-size values may be equal
+	_ byte
+}
 
 // NewKey creates a new Key
 func NewKey[V any]() *Key[V] {
@@ -89,10 +106,33 @@ func (k *Key[V]) WithValue(ctx context.Context, val V) context.Context {
 }
 
 func (k *Key[V]) Value(ctx context.Context) (V, bool) {
-	v, ok := ctx.Value(k).(V)
+	val := ctx.Value(k)
+	if _, ok := val.(cleared); ok {
+		var empty V
+		return empty, false
+	}
+	v, ok := val.(V)
 	return v, ok
 }
 
+// cleared is stored in a context to mark a Key as unset.
+type cleared struct{}
+
+// Clear returns a context in which the key has no value, even if a parent
+// context set one. context.Context values can't be deleted, so this stores a
+// marker that Value treats as unset; the parent context is not changed and
+// still returns its value. A later WithValue on the returned context sets the
+// key again.
+func (k *Key[V]) Clear(ctx context.Context) context.Context {
+	return context.WithValue(ctx, k, cleared{})
+}
+
+// Present returns true if a value has been stored for the key in ctx.
+func (k *Key[V]) Present(ctx context.Context) bool {
+	_, ok := k.Value(ctx)
+	return ok
+}
+
 func (k *Key[V]) MustValue(ctx context.Context) V {
 	v, ok := k.Value(ctx)
 	if !ok {
@@ -126,18 +166,120 @@ func (k *DefaultingKey[V]) Value(ctx context.Context) V {
 	return v
 }
 
+// Present returns true if a value has been stored for the key in ctx, i.e.
+// Value is not returning the default.
+func (k *DefaultingKey[V]) Present(ctx context.Context) bool {
+	_, ok := ctx.Value(k).(V)
+	return ok
+}
+
+// MustValue returns the value stored for the key, which may be the zero value
+// of V if that is what was stored. If no value was stored, it returns the
+// default value, and panics if the default is the zero value of V.
 func (k *DefaultingKey[V]) MustValue(ctx context.Context) V {
 	v, ok := ctx.Value(k).(V)
-	if !ok {
-		v = k.defaultValue
+	if ok {
+		return v
 	}
 	var empty V
-	if v == empty {
+	if k.defaultValue == empty {
 		panic(fmt.Sprintf("could not find non-nil value for key %T in context", k))
 	}
+	return k.defaultValue
+}
+
+// MustValuePresent returns the value stored for the key, even if it is the
+// zero value of V. Unlike MustValue, it ignores the default and panics only
+// if no value was stored.
+func (k *DefaultingKey[V]) MustValuePresent(ctx context.Context) V {
+	v, ok := ctx.Value(k).(V)
+	if !ok {
+		panic(fmt.Sprintf("could not find value for key %T in context", k))
+	}
+	return v
+}
+
+// SliceKey is a type that is used as a key in a context.Context for a slice
+// of values. Unlike DefaultingKey, V does not need to be comparable.
+type SliceKey[V any] struct {
+	// a non-zero size keeps keys distinct; see Key
+	_ byte
+}
+
+// NewSliceKey creates a new SliceKey
+func NewSliceKey[V any]() *SliceKey[V] {
+	return &SliceKey[V]{}
+}
+
+func (k *SliceKey[V]) WithValue(ctx context.Context, val []V) context.Context {
+	return context.WithValue(ctx, k, val)
+}
+
+// Value returns the slice stored for the key, or nil if unset.
+func (k *SliceKey[V]) Value(ctx context.Context) []V {
+	v, _ := ctx.Value(k).([]V)
+	return v
+}
+
+// Present returns true if a slice has been stored for the key in ctx.
+func (k *SliceKey[V]) Present(ctx context.Context) bool {
+	_, ok := ctx.Value(k).([]V)
+	return ok
+}
+
+// Append returns a context with vals appended to the slice stored for the key.
+// The slice in the parent context is not modified.
+func (k *SliceKey[V]) Append(ctx context.Context, vals ...V) context.Context {
+	existing := k.Value(ctx)
+	out := make([]V, 0, len(existing)+len(vals))
+	out = append(out, existing...)
+	out = append(out, vals...)
+	return k.WithValue(ctx, out)
+}
+
+// MapKey is a type that is used as a key in a context.Context for a map
+// of values. Unlike DefaultingKey, V does not need to be comparable.
+type MapKey[K comparable, V any] struct {
+	// a non-zero size keeps keys distinct; see Key
+	_ byte
+}
+
+// NewMapKey creates a new MapKey
+func NewMapKey[K comparable, V any]() *MapKey[K, V] {
+	return &MapKey[K, V]{}
+}
+
+func (k *MapKey[K, V]) WithValue(ctx context.Context, val map[K]V) context.Context {
+	return context.WithValue(ctx, k, val)
+}
+
+// Value returns the map stored for the key, or nil if unset.
+func (k *MapKey[K, V]) Value(ctx context.Context) map[K]V {
+	v, _ := ctx.Value(k).(map[K]V)
 	return v
 }
 
+// Present returns true if a map has been stored for the key in ctx.
+func (k *MapKey[K, V]) Present(ctx context.Context) bool {
+	_, ok := ctx.Value(k).(map[K]V)
+	return ok
+}
+
+// Merge returns a context with the entries of val added to the map stored for
+// the key. Entries in val replace existing entries with the same key. The map
+// in the parent context is not modified.
+func (k *MapKey[K, V]) Merge(ctx context.Context, val map[K]V) context.Context {
+	existing := k.Value(ctx)
+	out := make(map[K]V, len(existing)+len(val))
+	for mk, mv := range existing {
+		out[mk] = mv
+	}
+	for mk, mv := range val {
+		out[mk] = mv
+	}
+	return k.WithValue(ctx, out)
+}
+
 type Box[V any] struct {
 	value V
 }
@@ -180,10 +322,30 @@ func (k *BoxedKey[V]) Value(ctx context.Context) V {
 	return handle.value
 }
 
+// Present returns true if a box has been added to ctx for the key, either via
+// WithBox or WithValue.
+func (k *BoxedKey[V]) Present(ctx context.Context) bool {
+	_, ok := ctx.Value(k).(*Box[V])
+	return ok
+}
+
 func (k *BoxedKey[V]) MustValue(ctx context.Context) V {
 	return k.Value(ctx)
 }
 
+// Collect returns the boxed values from each of ctxs, in order. It is used to
+// gather the results of handlers that run with separate contexts, i.e. in
+// parallel: give each handler a context with its own box (via WithBox), then
+// collect the values they filled in. Contexts without a box contribute the
+// default value, so that the result always lines up with ctxs.
+func (k *BoxedKey[V]) Collect(ctxs ...context.Context) []V {
+	values := make([]V, 0, len(ctxs))
+	for _, ctx := range ctxs {
+		values = append(values, k.Value(ctx))
+	}
+	return values
+}
+
 // BoxBuilder returns a handler.Builder that calls Boxed before calling
 // the next handler in the chain.
 // This is a shortcut for using Boxed context values in handler chains, i.e.
@@ -196,3 +358,97 @@ func (k *BoxedKey[V]) BoxBuilder(id handler.Key) handler.Builder {
 		}, id)
 	}
 }
+
+// LazyBoxedKey is a BoxedKey whose value is computed on first access instead
+// of being set by a handler. The computed value is stored in the box, so
+// later reads in any context that shares the box return the same value
+// without computing it again.
+type LazyBoxedKey[V any] struct {
+	compute func(ctx context.Context) (V, error)
+}
+
+// lazyBox holds a lazily computed value. It is locked so that handlers
+// running in parallel on the same box only compute the value once.
+type lazyBox[V any] struct {
+	sync.Mutex
+	computed bool
+	value    V
+}
+
+// BoxedLazy creates a new LazyBoxedKey that uses compute to fill in the value
+// the first time it is read.
+func BoxedLazy[V any](compute func(ctx context.Context) (V, error)) *LazyBoxedKey[V] {
+	return &LazyBoxedKey[V]{
+		compute: compute,
+	}
+}
+
+// WithBox adds an empty box to the context, to be filled in on first access.
+func (k *LazyBoxedKey[V]) WithBox(ctx context.Context) context.Context {
+	return context.WithValue(ctx, k, &lazyBox[V]{})
+}
+
+// WithValue stores val in the box, so that compute is not called.
+func (k *LazyBoxedKey[V]) WithValue(ctx context.Context, val V) context.Context {
+	handle, ok := ctx.Value(k).(*lazyBox[V])
+	if !ok {
+		handle = &lazyBox[V]{}
+		ctx = context.WithValue(ctx, k, handle)
+	}
+	handle.Lock()
+	defer handle.Unlock()
+	handle.value = val
+	handle.computed = true
+	return ctx
+}
+
+// Value returns the value in the box, computing and storing it if it hasn't
+// been computed yet. Errors from compute are not stored, so the next read
+// will try again. If there is no box in the context, the value is computed
+// on every call.
+func (k *LazyBoxedKey[V]) Value(ctx context.Context) (V, error) {
+	handle, ok := ctx.Value(k).(*lazyBox[V])
+	if !ok {
+		return k.compute(ctx)
+	}
+	handle.Lock()
+	defer handle.Unlock()
+	if handle.computed {
+		return handle.value, nil
+	}
+	v, err := k.compute(ctx)
+	if err != nil {
+		return v, err
+	}
+	handle.value = v
+	handle.computed = true
+	return v, nil
+}
+
+// Present returns true if a box has been added to ctx for the key, either via
+// WithBox or WithValue.
+func (k *LazyBoxedKey[V]) Present(ctx context.Context) bool {
+	_, ok := ctx.Value(k).(*lazyBox[V])
+	return ok
+}
+
+// MustValue returns the value in the box, computing it if needed, and panics
+// if it can't be computed.
+func (k *LazyBoxedKey[V]) MustValue(ctx context.Context) V {
+	v, err := k.Value(ctx)
+	if err != nil {
+		panic(fmt.Sprintf("could not compute value for key %T: %v", k, err))
+	}
+	return v
+}
+
+// BoxBuilder returns a handler.Builder that calls WithBox before calling
+// the next handler in the chain.
+func (k *LazyBoxedKey[V]) BoxBuilder(id handler.Key) handler.Builder {
+	return func(next ...handler.Handler) handler.Handler {
+		return handler.NewHandlerFromFunc(func(ctx context.Context) {
+			ctx = k.WithBox(ctx)
+			handler.Handlers(next).MustOne().Handle(ctx)
+		}, id)
+	}
+}
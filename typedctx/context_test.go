@@ -2,7 +2,11 @@ package typedctx
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
 
 	"github.com/authzed/controller-idioms/handler"
 )
@@ -85,3 +89,250 @@ func ExampleBoxed() {
 	decorateHandler.Handle(ctx)
 	// Output: computed
 }
+
+func TestPresent(t *testing.T) {
+	key := NewKey[string]()
+	defaulting := WithDefault[string]("default")
+	boxed := Boxed[string]("default")
+
+	ctx := context.Background()
+	require.False(t, key.Present(ctx))
+	require.False(t, defaulting.Present(ctx))
+	require.False(t, boxed.Present(ctx))
+
+	ctx = key.WithValue(ctx, "value")
+	ctx = defaulting.WithValue(ctx, "value")
+	ctx = boxed.WithBox(ctx)
+	require.True(t, key.Present(ctx))
+	require.True(t, defaulting.Present(ctx))
+	require.True(t, boxed.Present(ctx))
+}
+
+func TestRequireContext(t *testing.T) {
+	key := NewKey[string]()
+	nextCalled := false
+	h := handler.RequireContext(handler.Required("value", key))(handler.NewHandlerFromFunc(func(_ context.Context) {
+		nextCalled = true
+	}, handler.NextKey))
+
+	require.PanicsWithValue(t, "required context key value not present", func() {
+		h.Handle(context.Background())
+	})
+	require.False(t, nextCalled)
+
+	h.Handle(key.WithValue(context.Background(), "value"))
+	require.True(t, nextCalled)
+}
+
+func TestKeyClear(t *testing.T) {
+	key := NewKey[string]()
+	anyKey := NewKey[any]()
+
+	parent := key.WithValue(context.Background(), "value")
+	parent = anyKey.WithValue(parent, "value")
+
+	ctx := key.Clear(parent)
+	ctx = anyKey.Clear(ctx)
+
+	v, ok := key.Value(ctx)
+	require.False(t, ok)
+	require.Empty(t, v)
+	require.False(t, key.Present(ctx))
+	require.Panics(t, func() { key.MustValue(ctx) })
+
+	anyV, ok := anyKey.Value(ctx)
+	require.False(t, ok)
+	require.Nil(t, anyV)
+
+	// the parent context is unchanged
+	require.Equal(t, "value", key.MustValue(parent))
+	require.Equal(t, "value", anyKey.MustValue(parent))
+
+	// the key can be set again after it is cleared
+	ctx = key.WithValue(ctx, "new")
+	require.Equal(t, "new", key.MustValue(ctx))
+}
+
+func TestBoxedLazy(t *testing.T) {
+	source := NewKey[int]()
+	computeCount := 0
+	lazy := BoxedLazy[string](func(ctx context.Context) (string, error) {
+		computeCount++
+		n, ok := source.Value(ctx)
+		if !ok {
+			return "", errors.New("no source value")
+		}
+		return fmt.Sprintf("computed-%d", n), nil
+	})
+
+	ctx := lazy.WithBox(context.Background())
+	require.True(t, lazy.Present(ctx))
+
+	// errors are returned and not cached
+	_, err := lazy.Value(ctx)
+	require.Error(t, err)
+	require.Panics(t, func() { lazy.MustValue(ctx) })
+	require.Equal(t, 2, computeCount)
+
+	computeCount = 0
+	ctx = source.WithValue(ctx, 1)
+	require.Equal(t, "computed-1", lazy.MustValue(ctx))
+	require.Equal(t, "computed-1", lazy.MustValue(ctx))
+
+	// the value persists for later readers of the same box, even after the
+	// source value changes
+	ctx = source.WithValue(ctx, 2)
+	require.Equal(t, "computed-1", lazy.MustValue(ctx))
+	require.Equal(t, 1, computeCount)
+
+	// a new box computes a new value
+	require.Equal(t, "computed-2", lazy.MustValue(lazy.WithBox(ctx)))
+	require.Equal(t, 2, computeCount)
+}
+
+func TestBoxedLazyWithValue(t *testing.T) {
+	lazy := BoxedLazy[string](func(_ context.Context) (string, error) {
+		require.Fail(t, "unexpected compute")
+		return "", nil
+	})
+	ctx := lazy.WithBox(context.Background())
+	require.Equal(t, ctx, lazy.WithValue(ctx, "set"))
+	require.Equal(t, "set", lazy.MustValue(ctx))
+}
+
+func TestBoxedLazyBoxBuilder(t *testing.T) {
+	computeCount := 0
+	lazy := BoxedLazy[string](func(_ context.Context) (string, error) {
+		computeCount++
+		return "computed", nil
+	})
+
+	var values []string
+	read := func(next ...handler.Handler) handler.Handler {
+		return handler.NewHandlerFromFunc(func(ctx context.Context) {
+			values = append(values, lazy.MustValue(ctx))
+			if len(next) > 0 {
+				handler.Handlers(next).MustOne().Handle(ctx)
+			}
+		}, "read")
+	}
+	handler.Chain(lazy.BoxBuilder("box"), read, read).Handler("lazy").Handle(context.Background())
+
+	require.Equal(t, []string{"computed", "computed"}, values)
+	require.Equal(t, 1, computeCount)
+}
+
+func TestDefaultingKeyZeroValue(t *testing.T) {
+	zeroDefault := WithDefault[int](0)
+	nonZeroDefault := WithDefault[int](10)
+
+	ctx := context.Background()
+	require.Panics(t, func() { zeroDefault.MustValue(ctx) })
+	require.Panics(t, func() { zeroDefault.MustValuePresent(ctx) })
+	require.Equal(t, 10, nonZeroDefault.MustValue(ctx))
+	require.Panics(t, func() { nonZeroDefault.MustValuePresent(ctx) })
+
+	ctx = zeroDefault.WithValue(ctx, 0)
+	ctx = nonZeroDefault.WithValue(ctx, 0)
+	require.Equal(t, 0, zeroDefault.MustValue(ctx))
+	require.Equal(t, 0, zeroDefault.MustValuePresent(ctx))
+	require.Equal(t, 0, nonZeroDefault.MustValue(ctx))
+	require.Equal(t, 0, nonZeroDefault.MustValuePresent(ctx))
+}
+
+func TestSliceKey(t *testing.T) {
+	key := NewSliceKey[string]()
+
+	var result []string
+	read := handler.NewHandlerFromFunc(func(ctx context.Context) {
+		result = key.Value(ctx)
+	}, "read")
+	second := handler.NewHandlerFromFunc(func(ctx context.Context) {
+		read.Handle(key.Append(ctx, "c"))
+	}, "second")
+	first := handler.NewHandlerFromFunc(func(ctx context.Context) {
+		require.False(t, key.Present(ctx))
+		require.Nil(t, key.Value(ctx))
+		second.Handle(key.Append(ctx, "a", "b"))
+	}, "first")
+
+	first.Handle(context.Background())
+	require.Equal(t, []string{"a", "b", "c"}, result)
+
+	// appending doesn't modify the slice in the parent context
+	parent := key.WithValue(context.Background(), make([]string, 1, 10))
+	_ = key.Append(parent, "x")
+	_ = key.Append(parent, "y")
+	require.Equal(t, []string{""}, key.Value(parent))
+}
+
+func TestMapKey(t *testing.T) {
+	key := NewMapKey[string, []int]()
+
+	var result map[string][]int
+	read := handler.NewHandlerFromFunc(func(ctx context.Context) {
+		result = key.Value(ctx)
+	}, "read")
+	second := handler.NewHandlerFromFunc(func(ctx context.Context) {
+		read.Handle(key.Merge(ctx, map[string][]int{"b": {3}, "c": {4}}))
+	}, "second")
+	first := handler.NewHandlerFromFunc(func(ctx context.Context) {
+		require.False(t, key.Present(ctx))
+		second.Handle(key.Merge(ctx, map[string][]int{"a": {1}, "b": {2}}))
+	}, "first")
+
+	first.Handle(context.Background())
+	require.Equal(t, map[string][]int{"a": {1}, "b": {3}, "c": {4}}, result)
+
+	// merging doesn't modify the map in the parent context
+	parent := key.WithValue(context.Background(), map[string][]int{"a": {1}})
+	_ = key.Merge(parent, map[string][]int{"b": {2}})
+	require.Equal(t, map[string][]int{"a": {1}}, key.Value(parent))
+}
+
+func TestKeysOfSameTypeAreDistinct(t *testing.T) {
+	ctx := context.Background()
+
+	key, otherKey := NewKey[string](), NewKey[string]()
+	require.NotSame(t, key, otherKey)
+	ctx = key.WithValue(ctx, "a")
+	require.False(t, otherKey.Present(ctx))
+	ctx = otherKey.WithValue(ctx, "b")
+	require.Equal(t, "a", key.MustValue(ctx))
+
+	sliceKey, otherSliceKey := NewSliceKey[string](), NewSliceKey[string]()
+	require.NotSame(t, sliceKey, otherSliceKey)
+	ctx = sliceKey.WithValue(ctx, []string{"a"})
+	require.False(t, otherSliceKey.Present(ctx))
+	ctx = otherSliceKey.WithValue(ctx, []string{"b"})
+	require.Equal(t, []string{"a"}, sliceKey.Value(ctx))
+
+	mapKey, otherMapKey := NewMapKey[string, int](), NewMapKey[string, int]()
+	require.NotSame(t, mapKey, otherMapKey)
+	ctx = mapKey.WithValue(ctx, map[string]int{"a": 1})
+	require.False(t, otherMapKey.Present(ctx))
+	ctx = otherMapKey.WithValue(ctx, map[string]int{"b": 2})
+	require.Equal(t, map[string]int{"a": 1}, mapKey.Value(ctx))
+}
+
+func TestBoxedCollect(t *testing.T) {
+	boxed := Boxed[string]("default")
+	fill := func(val string) handler.Handler {
+		return handler.NewHandlerFromFunc(func(ctx context.Context) {
+			boxed.WithValue(ctx, val)
+		}, handler.Key("fill-"+val))
+	}
+
+	ctx := context.Background()
+	first := boxed.WithBox(ctx)
+	second := boxed.WithBox(ctx)
+	fill("first").Handle(first)
+	fill("second").Handle(second)
+
+	require.Equal(t, []string{"first", "second"}, boxed.Collect(first, second))
+
+	// the parent context doesn't see the children's values, and a context
+	// without a box contributes the default
+	require.Equal(t, []string{"first", "default", "second"}, boxed.Collect(first, ctx, second))
+	require.Empty(t, boxed.Collect())
+}
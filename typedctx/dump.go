@@ -0,0 +1,124 @@
+package typedctx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// namedKey is a key that has been given a name for debugging, and a func
+// that returns its value in a context, if one is set.
+type namedKey struct {
+	key   any
+	value func(ctx context.Context) (any, bool)
+}
+
+// namedKeys holds the keys that have been given a name for debugging, by
+// name.
+var (
+	namedKeysLock sync.RWMutex
+	namedKeys     = map[string]namedKey{}
+)
+
+// registerName adds a named key to the registry used by Dump. Names are
+// process-wide, so a name that is already registered for another key (i.e.
+// by a table test that creates a key per case, or by two libraries that pick
+// the same name) is suffixed with "#2", "#3", etc. so that Dump shows both.
+// Naming the same key again with the same name has no effect.
+func registerName(name string, key any, value func(ctx context.Context) (any, bool)) {
+	namedKeysLock.Lock()
+	defer namedKeysLock.Unlock()
+	unique := name
+	for i := 2; ; i++ {
+		existing, ok := namedKeys[unique]
+		if !ok {
+			break
+		}
+		if existing.key == key {
+			return
+		}
+		unique = fmt.Sprintf("%s#%d", name, i)
+	}
+	namedKeys[unique] = namedKey{key: key, value: value}
+}
+
+// Dump returns the values of all named keys that are set in ctx, by name.
+// It is meant for debugging handler chains, i.e. logging the state of the
+// context when a handler fails. Only keys that have been given a name with
+// their Named method are included, so that keys pay no cost unless they opt
+// in. Names are process-wide: if a name is already taken by another key, the
+// later key is dumped under the name suffixed with "#2", "#3", etc.
+func Dump(ctx context.Context) map[string]any {
+	namedKeysLock.RLock()
+	defer namedKeysLock.RUnlock()
+	values := make(map[string]any)
+	for name, k := range namedKeys {
+		if v, ok := k.value(ctx); ok {
+			values[name] = v
+		}
+	}
+	return values
+}
+
+// Named registers the key under name for Dump, and returns the key.
+func (k *Key[V]) Named(name string) *Key[V] {
+	registerName(name, k, func(ctx context.Context) (any, bool) {
+		return k.Value(ctx)
+	})
+	return k
+}
+
+// Named registers the key under name for Dump, and returns the key. The
+// default value is not dumped; only values stored in the context are.
+func (k *DefaultingKey[V]) Named(name string) *DefaultingKey[V] {
+	registerName(name, k, func(ctx context.Context) (any, bool) {
+		v, ok := ctx.Value(k).(V)
+		return v, ok
+	})
+	return k
+}
+
+// Named registers the key under name for Dump, and returns the key. The
+// value is dumped if there is a box in the context.
+func (k *BoxedKey[V]) Named(name string) *BoxedKey[V] {
+	registerName(name, k, func(ctx context.Context) (any, bool) {
+		if !k.Present(ctx) {
+			return nil, false
+		}
+		return k.Value(ctx), true
+	})
+	return k
+}
+
+// Named registers the key under name for Dump, and returns the key. The
+// value is only dumped once it has been computed; Dump never computes it.
+func (k *LazyBoxedKey[V]) Named(name string) *LazyBoxedKey[V] {
+	registerName(name, k, func(ctx context.Context) (any, bool) {
+		handle, ok := ctx.Value(k).(*lazyBox[V])
+		if !ok {
+			return nil, false
+		}
+		handle.Lock()
+		defer handle.Unlock()
+		return handle.value, handle.computed
+	})
+	return k
+}
+
+// Named registers the key under name for Dump, and returns the key.
+func (k *SliceKey[V]) Named(name string) *SliceKey[V] {
+	registerName(name, k, func(ctx context.Context) (any, bool) {
+		v, ok := ctx.Value(k).([]V)
+		return v, ok
+	})
+	return k
+}
+
+// Named registers the key under name for Dump, and returns the key.
+func (k *MapKey[K, V]) Named(name string) *MapKey[K, V] {
+	registerName(name, k, func(ctx context.Context) (any, bool) {
+		v, ok := ctx.Value(k).(map[K]V)
+		return v, ok
+	})
+	return k
+}
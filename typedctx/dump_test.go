@@ -0,0 +1,67 @@
+package typedctx
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type dumpTestOwner struct {
+	Name string `json:"name"`
+}
+
+// named keys are registered globally, so they're declared once for the
+// package rather than per test run
+var (
+	ownerKey      = NewKey[dumpTestOwner]().Named("dump-test/owner")
+	resultKey     = Boxed[string]("").Named("dump-test/result")
+	defaultingKey = WithDefault[string]("default").Named("dump-test/defaulting")
+)
+
+func TestDump(t *testing.T) {
+	unnamedKey := NewKey[string]()
+
+	ctx := context.Background()
+	require.NotContains(t, Dump(ctx), "dump-test/owner")
+
+	ctx = ownerKey.WithValue(ctx, dumpTestOwner{Name: "test"})
+	ctx = resultKey.WithBox(ctx)
+	resultKey.WithValue(ctx, "done")
+	ctx = unnamedKey.WithValue(ctx, "unnamed")
+
+	dump := Dump(ctx)
+	require.Equal(t, dumpTestOwner{Name: "test"}, dump["dump-test/owner"])
+	require.Equal(t, "done", dump["dump-test/result"])
+	// the default of an unset key is not dumped
+	require.NotContains(t, dump, "dump-test/defaulting")
+	require.NotContains(t, dump, "unnamed")
+
+	out, err := json.Marshal(map[string]any{
+		"owner":  dump["dump-test/owner"],
+		"result": dump["dump-test/result"],
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"owner":{"name":"test"},"result":"done"}`, string(out))
+
+	ctx = defaultingKey.WithValue(ctx, "set")
+	require.Equal(t, "set", Dump(ctx)["dump-test/defaulting"])
+
+	// a duplicate name is suffixed rather than replacing the first key, and
+	// naming the same key again has no effect
+	duplicateKey := NewKey[string]().Named("dump-test/owner")
+	require.NotPanics(t, func() { ownerKey.Named("dump-test/owner") })
+	ctx = duplicateKey.WithValue(ctx, "duplicate")
+	dump = Dump(ctx)
+	require.Equal(t, dumpTestOwner{Name: "test"}, dump["dump-test/owner"])
+	// other runs of this test may have taken "#2" already
+	var duplicates []string
+	for name, v := range dump {
+		if strings.HasPrefix(name, "dump-test/owner#") && v == "duplicate" {
+			duplicates = append(duplicates, name)
+		}
+	}
+	require.Len(t, duplicates, 1)
+}